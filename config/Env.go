@@ -0,0 +1,50 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetDuration reads a duration such as "90s" or "72h" from the environment.
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Fatalf("❌ Invalid %s: %q", key, value)
+	}
+	return duration
+}
+
+// GetInt64 reads a positive whole number from the environment.
+func GetInt64(key string, fallback int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number <= 0 {
+		log.Fatalf("❌ Invalid %s: %q", key, value)
+	}
+	return number
+}
+
+// GetBool reads a boolean such as "true" or "0" from the environment.
+func GetBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("❌ Invalid %s: %q", key, value)
+	}
+	return b
+}
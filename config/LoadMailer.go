@@ -0,0 +1,56 @@
+package config
+
+import (
+	"log"
+	"os"
+	"task-manager/internal/mailer"
+	"time"
+)
+
+var Mailer mailer.Mailer = mailer.Log{}
+
+// PasswordResetTTL is how long a password reset token can be used.
+// PasswordResetInterval is how long an account waits between reset emails.
+// PasswordResetURL, when set, is the page the reset email links to with the
+// token as the token query parameter.
+var (
+	PasswordResetTTL      = time.Hour
+	PasswordResetInterval = time.Minute
+	PasswordResetURL      string
+)
+
+func LoadMailer() {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	switch backend := os.Getenv("MAILER"); backend {
+	case "", "log":
+		Mailer = mailer.Log{}
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		Mailer = mailer.File{Dir: dir, From: from}
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		Mailer = mailer.SMTP{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	default:
+		log.Fatalf("❌ Invalid MAILER: %q", backend)
+	}
+
+	PasswordResetTTL = GetDuration("PASSWORD_RESET_TTL", PasswordResetTTL)
+	PasswordResetInterval = GetDuration("PASSWORD_RESET_INTERVAL", PasswordResetInterval)
+	PasswordResetURL = os.Getenv("PASSWORD_RESET_URL")
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"task-manager/internal/revocation"
+	"time"
+)
+
+var Revocations = &revocation.Store{}
+
+// LoadRevocations sets up the store of logged out tokens. It needs ConnectDB.
+func LoadRevocations() {
+	Revocations = &revocation.Store{
+		DB:            DB,
+		CacheTTL:      GetDuration("REVOCATION_CACHE_TTL", 30*time.Second),
+		PurgeInterval: GetDuration("REVOCATION_PURGE_INTERVAL", time.Hour),
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// AccessTokenTTL is how long a JWT is accepted, RefreshTokenTTL how long the
+// refresh token issued with it can be exchanged for a new one.
+var (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// AuthPrecedence is where AuthMiddleware looks first when a request carries
+// both an Authorization header and a jwt cookie: "header" or "cookie".
+var AuthPrecedence = "header"
+
+func LoadSessions() {
+	AccessTokenTTL = GetDuration("ACCESS_TOKEN_TTL", AccessTokenTTL)
+	RefreshTokenTTL = GetDuration("REFRESH_TOKEN_TTL", RefreshTokenTTL)
+
+	switch precedence := os.Getenv("AUTH_PRECEDENCE"); precedence {
+	case "":
+	case "header", "cookie":
+		AuthPrecedence = precedence
+	default:
+		log.Fatalf("❌ Invalid AUTH_PRECEDENCE: %q", precedence)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"log"
+	"os"
+	"task-manager/internal/storage"
+)
+
+var Storage storage.Storage
+
+// AttachmentMaxSize is the largest file in bytes that can be attached to a task.
+var AttachmentMaxSize int64 = 10 << 20
+
+func LoadStorage() {
+	AttachmentMaxSize = GetInt64("ATTACHMENT_MAX_SIZE", AttachmentMaxSize)
+
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		root := os.Getenv("STORAGE_PATH")
+		if root == "" {
+			root = "uploads"
+		}
+		Storage = storage.Local{Root: root}
+	case "s3":
+		Storage = storage.S3{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		}
+	default:
+		log.Fatalf("❌ Invalid STORAGE_BACKEND: %q", backend)
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log"
+	"os"
+	"task-manager/internal/workflow"
+)
+
+var Workflow = workflow.Default()
+
+func LoadWorkflow() {
+	spec := os.Getenv("TASK_WORKFLOW")
+	if spec == "" {
+		return
+	}
+
+	wf, err := workflow.Parse(spec)
+	if err != nil {
+		log.Fatal("❌ Invalid TASK_WORKFLOW:", err)
+	}
+	Workflow = wf
+}
@@ -1,8 +1,63 @@
 package config
 
-import "task-manager/internal/models"
+import (
+	"task-manager/internal/models"
+
+	"gorm.io/gorm"
+)
 
 func SyncDB() {
 	DB.AutoMigrate(&models.User{})
+	DB.AutoMigrate(&models.RevokedToken{})
+	DB.AutoMigrate(&models.RefreshToken{})
+	DB.AutoMigrate(&models.PersonalAccessToken{})
+	DB.AutoMigrate(&models.PasswordReset{})
+	DB.AutoMigrate(&models.Team{})
+	DB.AutoMigrate(&models.Membership{})
+	DB.AutoMigrate(&models.TeamInvitation{})
+	DB.AutoMigrate(&models.Project{})
 	DB.AutoMigrate(&models.Task{})
+	DB.AutoMigrate(&models.TaskShare{})
+	DB.AutoMigrate(&models.TaskAssignee{})
+	DB.AutoMigrate(&models.Comment{})
+	DB.AutoMigrate(&models.CommentRevision{})
+	DB.AutoMigrate(&models.CommentMention{})
+	DB.AutoMigrate(&models.Attachment{})
+	DB.AutoMigrate(&models.TaskHistory{})
+	DB.AutoMigrate(&models.TaskStatusChange{})
+	DB.AutoMigrate(&models.Label{})
+	DB.AutoMigrate(&models.ChecklistItem{})
+	DB.AutoMigrate(&models.TaskDependency{})
+
+	migrateTaskStatuses()
+	createTaskIndexes()
+}
+
+// Keyset pagination orders by the sort column and then by id
+func createTaskIndexes() {
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_created_at_id ON tasks (created_at, id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_updated_at_id ON tasks (updated_at, id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_status_changed_at_id ON tasks (status_changed_at, id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_title_id ON tasks (title, id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_status_id ON tasks (status, id)")
+
+	// Trigram indexes serve the ILIKE text search when pg_trgm is available
+	if DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error == nil {
+		DB.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_title_trgm ON tasks USING gin (title gin_trgm_ops)")
+		DB.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_description_trgm ON tasks USING gin (description gin_trgm_ops)")
+	}
+}
+
+// Statuses used to be stored as 0 = new, 1 = ongoing, 2 = completed
+func migrateTaskStatuses() {
+	legacy := map[string]models.TaskStatus{
+		"0": models.StatusNew,
+		"1": models.StatusOngoing,
+		"2": models.StatusCompleted,
+	}
+	for old, status := range legacy {
+		DB.Unscoped().Model(&models.Task{}).Where("status = ?", old).Update("status", status)
+	}
+
+	DB.Unscoped().Model(&models.Task{}).Where("status_changed_at IS NULL").Update("status_changed_at", gorm.Expr("updated_at"))
 }
@@ -0,0 +1,48 @@
+package depgraph
+
+// Edge says that Task is blocked by BlockedBy.
+type Edge struct {
+	Task      uint `json:"task_id"`
+	BlockedBy uint `json:"blocked_by_id"`
+}
+
+type Graph struct {
+	blockers map[uint][]uint
+}
+
+func New(edges []Edge) *Graph {
+	g := &Graph{blockers: map[uint][]uint{}}
+	for _, edge := range edges {
+		g.blockers[edge.Task] = append(g.blockers[edge.Task], edge.BlockedBy)
+	}
+	return g
+}
+
+// DependsOn reports whether task is blocked by other, directly or through
+// other blockers.
+func (g *Graph) DependsOn(task, other uint) bool {
+	seen := map[uint]bool{task: true}
+	queue := []uint{task}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, blocker := range g.blockers[current] {
+			if blocker == other {
+				return true
+			}
+			if !seen[blocker] {
+				seen[blocker] = true
+				queue = append(queue, blocker)
+			}
+		}
+	}
+
+	return false
+}
+
+// WouldCycle reports whether blocking task by blocker would close a loop.
+func (g *Graph) WouldCycle(task, blocker uint) bool {
+	return task == blocker || g.DependsOn(blocker, task)
+}
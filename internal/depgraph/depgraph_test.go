@@ -0,0 +1,37 @@
+package depgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWouldCycle(t *testing.T) {
+	// 1 is blocked by 2, 2 by 3 and 4, 4 by 5
+	graph := New([]Edge{
+		{Task: 1, BlockedBy: 2},
+		{Task: 2, BlockedBy: 3},
+		{Task: 2, BlockedBy: 4},
+		{Task: 4, BlockedBy: 5},
+	})
+
+	tests := []struct {
+		name      string
+		task      uint
+		blocker   uint
+		wantCycle bool
+	}{
+		{name: "Self Dependency", task: 1, blocker: 1, wantCycle: true},
+		{name: "Direct Cycle", task: 2, blocker: 1, wantCycle: true},
+		{name: "Transitive Cycle", task: 5, blocker: 1, wantCycle: true},
+		{name: "Sibling Branch", task: 3, blocker: 5, wantCycle: false},
+		{name: "Redundant Edge", task: 1, blocker: 5, wantCycle: false},
+		{name: "Unrelated Task", task: 6, blocker: 1, wantCycle: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantCycle, graph.WouldCycle(tt.task, tt.blocker))
+		})
+	}
+}
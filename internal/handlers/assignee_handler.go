@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task-manager/config"
+	"task-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errAssigneeNotFound = errors.New("Assignee not found")
+
+// checkAssignee makes sure the user belongs where the task lives: tasks of a
+// team project go to team members who can work on them, other tasks to their
+// creator and the users they are shared with.
+func checkAssignee(db *gorm.DB, task models.Task, userID uint) error {
+	if task.ProjectID != nil {
+		var project models.Project
+		err := db.First(&project, *task.ProjectID).Error
+		if err != nil {
+			return fmt.Errorf("Project not found")
+		}
+
+		if project.TeamID != nil {
+			if !teamRole(userID, *project.TeamID).AtLeast(models.RoleMember) {
+				return fmt.Errorf("Assignee must be a member of the project's team")
+			}
+			return nil
+		}
+	}
+
+	if task.CreatedBy == userID {
+		return nil
+	}
+
+	var count int64
+	db.Model(&models.TaskShare{}).Where("task_id = ? AND user_id = ?", task.ID, userID).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("Assignee must be the task's creator or have the task shared with them")
+	}
+
+	return nil
+}
+
+// pruneAssignees drops the assignments of users who no longer belong to the
+// task, after it moved to another project or was unshared. It runs in the
+// transaction of that change, so the two are saved together.
+func pruneAssignees(tx *gorm.DB, task *models.Task) error {
+	var assignees []models.TaskAssignee
+	if err := tx.Where("task_id = ?", task.ID).Find(&assignees).Error; err != nil {
+		return err
+	}
+
+	pruned := false
+	for _, assignee := range assignees {
+		if checkAssignee(tx, *task, assignee.UserID) == nil {
+			continue
+		}
+		if err := tx.Delete(&assignee).Error; err != nil {
+			return err
+		}
+		pruned = true
+	}
+
+	if !pruned {
+		return nil
+	}
+	return touchTask(tx, task)
+}
+
+// unassignFromTeam drops the user's assignments on tasks of the team's projects.
+func unassignFromTeam(db *gorm.DB, teamID uint, userID uint) error {
+	teamTasks := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Task{}).
+		Select("tasks.id").
+		Joins("JOIN projects ON projects.id = tasks.project_id").
+		Where("projects.team_id = ?", teamID)
+
+	err := touchTasks(db, "id IN (SELECT task_id FROM task_assignees WHERE user_id = ? AND task_id IN (?))", userID, teamTasks)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ? AND task_id IN (?)", userID, teamTasks).Delete(&models.TaskAssignee{}).Error
+}
+
+func AssignTask(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	// Find user to assign
+	var user models.User
+	err := config.DB.First(&user, body.UserID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	if err := checkAssignee(config.DB, task, user.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignee := models.TaskAssignee{TaskID: task.ID, UserID: user.ID}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		created := tx.Where(assignee).Attrs(models.TaskAssignee{AssignedBy: userID}).FirstOrCreate(&assignee)
+		if created.Error != nil || created.RowsAffected == 0 {
+			return created.Error
+		}
+		return touchTask(tx, &task)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error assigning task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Task assigned successfully",
+		"assignee": assignee,
+	})
+}
+
+func UnassignTask(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	// Assignees may always hand a task back
+	access := accessWrite
+	if c.Param("user_id") == strconv.FormatUint(uint64(userID), 10) {
+		access = accessRead
+	}
+
+	task, ok := findTask(c, c.Param("id"), access)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("task_id = ? AND user_id = ?", task.ID, c.Param("user_id")).Delete(&models.TaskAssignee{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAssigneeNotFound
+		}
+		return touchTask(tx, &task)
+	})
+
+	if err == errAssigneeNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error unassigning task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task unassigned successfully",
+	})
+}
+
+func GetAssignedTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	query, err := parseTaskQuery(c.Request.URL.Query(), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	query.assignee = &userID
+
+	var tasks []models.Task
+	err = config.DB.Scopes(visibleTasks(userID), query.scope).Preload("Labels").Preload("Assignees").Find(&tasks).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find tasks",
+		})
+		return
+	}
+
+	tasks, nextCursor := query.page(tasks)
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":       tasks,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestPruningAssigneesIsPartOfTheChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+	}{
+		{name: "Unshare", method: "DELETE", url: "/v1/tasks/42/shares/8"},
+		{name: "Patch Project", method: "PATCH", url: "/v1/tasks/42", body: `{"project_id":null}`},
+		{name: "Legacy Update Project", method: "PUT", url: "/task/update?task_id=42", body: `{"project_id":0}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			// The caller owns a task of project 3 that user 8 is assigned to,
+			// without it being shared with them
+			onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+				if task, ok := tx.Statement.Dest.(*models.Task); ok {
+					projectID := uint(3)
+					task.ProjectID = &projectID
+				}
+			})
+			onQuery("FROM task_assignees WHERE task_id", func(tx *gorm.DB) {
+				if assignees, ok := tx.Statement.Dest.(*[]models.TaskAssignee); ok {
+					*assignees = []models.TaskAssignee{{ID: 5, TaskID: 42, UserID: 8}}
+				}
+			})
+
+			pruned, inTransaction := false, false
+			config.DB.Callback().Delete().After("test:capture_delete").Register("test:prune", func(tx *gorm.DB) {
+				if strings.Contains(explain(tx), "DELETE FROM task_assignees") {
+					_, inTransaction = tx.Statement.ConnPool.(*sql.Tx)
+					pruned = true
+				}
+			})
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(0))
+			})
+			router.DELETE("/v1/tasks/:id/shares/:user_id", UnshareTask)
+			router.PATCH("/v1/tasks/:id", PatchTask)
+			router.PUT("/task/update", UpdateTasks)
+
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.True(t, pruned)
+			assert.True(t, inTransaction)
+		})
+	}
+}
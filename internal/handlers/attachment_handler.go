@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/storage"
+	"task-manager/internal/tokens"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxFilenameLength = 255
+
+// multipartOverhead leaves room for the multipart headers around the file.
+const multipartOverhead = 1 << 20
+
+func attachmentFilename(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		name = "file"
+	}
+	if len(name) > maxFilenameLength {
+		name = name[len(name)-maxFilenameLength:]
+	}
+	return name
+}
+
+func findAttachment(c *gin.Context, task models.Task) (models.Attachment, bool) {
+	var attachment models.Attachment
+	err := config.DB.First(&attachment, "id = ? AND task_id = ?", c.Param("attachment_id"), task.ID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Attachment not found",
+		})
+		return attachment, false
+	}
+
+	return attachment, true
+}
+
+func UploadAttachment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	tooLarge := gin.H{"error": fmt.Sprintf("File is larger than %d bytes", config.AttachmentMaxSize)}
+
+	// Stop reading oversized uploads early instead of spooling them to disk
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.AttachmentMaxSize+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file field"})
+		return
+	}
+
+	if header.Size > config.AttachmentMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error reading file"})
+		return
+	}
+	defer file.Close()
+
+	// The declared content type can't be trusted, so look at the content
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error reading file"})
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error reading file"})
+		return
+	}
+
+	name, err := tokens.Generate("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error storing file"})
+		return
+	}
+
+	attachment := models.Attachment{
+		TaskID:      task.ID,
+		UploadedBy:  userID,
+		Filename:    attachmentFilename(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		StorageKey:  fmt.Sprintf("tasks/%d/%s", task.ID, name),
+	}
+
+	hash := sha256.New()
+	err = config.Storage.Put(c.Request.Context(), attachment.StorageKey, io.TeeReader(file, hash), header.Size, contentType)
+	if err != nil {
+		log.Printf("failed to store attachment for task %d: %v", task.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error storing file"})
+		return
+	}
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	if err := config.DB.Create(&attachment).Error; err != nil {
+		config.Storage.Delete(c.Request.Context(), attachment.StorageKey)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error creating attachment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Attachment uploaded successfully",
+		"attachment": attachment,
+	})
+}
+
+func GetAttachments(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	var attachments []models.Attachment
+	err := config.DB.Where("task_id = ?", task.ID).Order("created_at, id").Find(&attachments).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find attachments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment streams the content. Range and conditional requests are
+// handled by http.ServeContent.
+func DownloadAttachment(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	attachment, ok := findAttachment(c, task)
+	if !ok {
+		return
+	}
+
+	object, err := config.Storage.Open(c.Request.Context(), attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment content not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("failed to open attachment %d: %v", attachment.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reading file"})
+		return
+	}
+	defer object.Close()
+
+	// Never let browsers render uploads inline
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", `"`+attachment.Checksum+`"`)
+
+	http.ServeContent(c.Writer, c.Request, attachment.Filename, attachment.CreatedAt, object)
+}
+
+func DeleteAttachment(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	attachment, ok := findAttachment(c, task)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Unscoped().Delete(&attachment).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error deleting attachment",
+		})
+		return
+	}
+
+	if err := config.Storage.Delete(c.Request.Context(), attachment.StorageKey); err != nil {
+		log.Printf("failed to delete attachment %d content: %v", attachment.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attachment deleted successfully",
+	})
+}
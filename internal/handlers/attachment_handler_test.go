@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func createMultipartRequest(url, filename, contentType string, content []byte) (*http.Request, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	part.Write(content)
+	writer.Close()
+
+	req, err := http.NewRequest("POST", url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req, nil
+}
+
+func TestUploadAttachment(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	config.Storage = storage.Local{Root: t.TempDir()}
+	config.AttachmentMaxSize = 64
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	})
+	router.POST("/task/:id/attachments", UploadAttachment)
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+
+	tests := []struct {
+		name           string
+		filename       string
+		contentType    string
+		content        []byte
+		expectedStatus int
+		expectedType   string
+		expectedName   string
+	}{
+		{
+			name:           "Content Type Is Sniffed",
+			filename:       "diagram.txt",
+			contentType:    "text/plain",
+			content:        png,
+			expectedStatus: http.StatusOK,
+			expectedType:   "image/png",
+			expectedName:   "diagram.txt",
+		},
+		{
+			name:           "Path In Filename Is Dropped",
+			filename:       "../../notes.txt",
+			contentType:    "application/octet-stream",
+			content:        []byte("hello"),
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/plain; charset=utf-8",
+			expectedName:   "notes.txt",
+		},
+		{
+			name:           "File Too Large",
+			filename:       "big.bin",
+			contentType:    "application/octet-stream",
+			content:        make([]byte, 65),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := createMultipartRequest("/task/42/attachments", tt.filename, tt.contentType, tt.content)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Attachment struct {
+					Filename    string `json:"filename"`
+					ContentType string `json:"content_type"`
+					Size        int64  `json:"size"`
+					Checksum    string `json:"checksum"`
+				} `json:"attachment"`
+			}
+			err = json.Unmarshal(recorder.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.expectedName, response.Attachment.Filename)
+			assert.Equal(t, tt.expectedType, response.Attachment.ContentType)
+			assert.Equal(t, int64(len(tt.content)), response.Attachment.Size)
+			checksum := sha256.Sum256(tt.content)
+			assert.Equal(t, hex.EncodeToString(checksum[:]), response.Attachment.Checksum)
+		})
+	}
+}
+
+func TestDeleteTaskKeepsAttachments(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	store := storage.Local{Root: t.TempDir()}
+	config.Storage = store
+	assert.NoError(t, store.Put(context.Background(), "1/report.pdf", strings.NewReader("pdf"), 3, "application/pdf"))
+
+	config.DB.Callback().Query().After("test:primary_key").Register("test:owner", func(tx *gorm.DB) {
+		if task, ok := tx.Statement.Dest.(*models.Task); ok {
+			task.CreatedBy = 7
+		}
+	})
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	})
+	router.DELETE("/v1/tasks/:id", DeleteTask)
+
+	req, err := createJSONRequest("DELETE", "/v1/tasks/1", nil)
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// Deleted tasks can be restored from the trash, so their attachments are
+	// only removed when the task is purged
+	for _, statement := range *statements {
+		assert.NotContains(t, statement, "attachments")
+	}
+	object, err := store.Open(context.Background(), "1/report.pdf")
+	assert.NoError(t, err)
+	object.Close()
+}
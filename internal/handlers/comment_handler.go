@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/mentions"
+	"task-manager/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxCommentLength = 10000
+
+func validateComment(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxCommentLength {
+		return "", fmt.Errorf("Comment must be 1 to %d characters", maxCommentLength)
+	}
+	return body, nil
+}
+
+// recordMentions replaces the comment's mentions with the users named in its
+// body. Only users who can see the task are recorded, so mentioning someone
+// never tells them about a task they have no access to.
+func recordMentions(tx *gorm.DB, comment *models.Comment) error {
+	if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+		return err
+	}
+	comment.Mentions = nil
+
+	usernames := mentions.Parse(comment.Body)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	var users []models.User
+	if err := tx.Where("username IN ?", usernames).Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		var visible int64
+		err := tx.Model(&models.Task{}).Scopes(visibleTasks(user.ID)).Where("tasks.id = ?", comment.TaskID).Count(&visible).Error
+		if err != nil {
+			return err
+		}
+		if visible == 0 {
+			continue
+		}
+
+		mention := models.CommentMention{CommentID: comment.ID, UserID: user.ID}
+		if err := tx.Create(&mention).Error; err != nil {
+			return err
+		}
+		comment.Mentions = append(comment.Mentions, mention)
+	}
+
+	return nil
+}
+
+func findComment(c *gin.Context, task models.Task) (models.Comment, bool) {
+	var comment models.Comment
+	err := config.DB.First(&comment, "id = ? AND task_id = ?", c.Param("comment_id"), task.ID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Comment not found",
+		})
+		return comment, false
+	}
+
+	return comment, true
+}
+
+func GetComments(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	var comments []models.Comment
+	err := config.DB.Where("task_id = ?", task.ID).Preload("Mentions").Order("created_at, id").Find(&comments).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find comments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// CreateComment is open to everyone who can see the task, including viewers
+// and read-only shares, since discussing a task doesn't change it.
+func CreateComment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Body string `json:"body" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	text, err := validateComment(body.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment := models.Comment{TaskID: task.ID, AuthorID: userID, Body: text}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return recordMentions(tx, &comment)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error creating comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment created successfully",
+		"comment": comment,
+	})
+}
+
+func UpdateComment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	comment, ok := findComment(c, task)
+	if !ok {
+		return
+	}
+
+	if comment.AuthorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the author can edit a comment",
+		})
+		return
+	}
+
+	var body struct {
+		Body string `json:"body" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	text, err := validateComment(body.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Keep the previous text before replacing it
+	revision := models.CommentRevision{CommentID: comment.ID, Body: comment.Body, EditedBy: userID}
+	now := time.Now()
+	comment.Body = text
+	comment.EditedAt = &now
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&comment).Updates(map[string]interface{}{"body": comment.Body, "edited_at": comment.EditedAt}).Error; err != nil {
+			return err
+		}
+		return recordMentions(tx, &comment)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment updated successfully",
+		"comment": comment,
+	})
+}
+
+// DeleteComment soft deletes the comment, so its text and history stay in
+// the database. Authors and the people who manage the task may delete it.
+func DeleteComment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	comment, ok := findComment(c, task)
+	if !ok {
+		return
+	}
+
+	if comment.AuthorID != userID && !canManageTask(task, userID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You don't have permission to delete this comment",
+		})
+		return
+	}
+
+	err := config.DB.Delete(&comment).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error deleting comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment deleted successfully",
+	})
+}
+
+func GetCommentHistory(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	comment, ok := findComment(c, task)
+	if !ok {
+		return
+	}
+
+	var revisions []models.CommentRevision
+	err := config.DB.Where("comment_id = ?", comment.ID).Order("created_at, id").Find(&revisions).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find comment history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comment":   comment,
+		"revisions": revisions,
+	})
+}
+
+// GetMentions lists the comments the user was mentioned in, newest first.
+func GetMentions(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	mentioned := config.DB.Session(&gorm.Session{NewDB: true}).
+		Model(&models.CommentMention{}).
+		Select("comment_id").
+		Where("user_id = ?", userID)
+
+	visible := config.DB.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Task{}).
+		Select("tasks.id").
+		Scopes(visibleTasks(userID))
+
+	var comments []models.Comment
+	err := config.DB.
+		Where("id IN (?) AND task_id IN (?)", mentioned, visible).
+		Preload("Mentions").
+		Order("created_at DESC, id DESC").
+		Limit(defaultTaskLimit).
+		Find(&comments).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find mentions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
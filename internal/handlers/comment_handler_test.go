@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// loadComment makes a dry run load task 42 of user 1 with comment 5 by user 7.
+func loadComment() {
+	onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+		if task, ok := tx.Statement.Dest.(*models.Task); ok {
+			task.ID = 42
+			task.CreatedBy = 1
+		}
+	})
+	onQuery("FROM comments WHERE", func(tx *gorm.DB) {
+		if comment, ok := tx.Statement.Dest.(*models.Comment); ok {
+			comment.ID = 5
+			comment.TaskID = 42
+			comment.AuthorID = 7
+			comment.Body = "Old text"
+		}
+	})
+}
+
+func TestUpdateComment(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		expectedStatus int
+	}{
+		{name: "Author", userID: 7, expectedStatus: http.StatusOK},
+		{name: "Task Owner", userID: 1, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			loadComment()
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", tt.userID)
+			})
+			router.PUT("/v1/tasks/:id/comments/:comment_id", UpdateComment)
+
+			req, err := createJSONRequest("PUT", "/v1/tasks/42/comments/5", map[string]interface{}{"body": "New text"})
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			if tt.expectedStatus != http.StatusOK {
+				assert.NotContains(t, all, "INSERT INTO comment_revisions")
+				assert.NotContains(t, all, "UPDATE comments")
+				return
+			}
+
+			// The revision keeps the text from before the edit
+			assert.Contains(t, all, "INSERT INTO comment_revisions (comment_id,body,edited_by,created_at) VALUES (5,'Old text',7,")
+			assert.Contains(t, all, "UPDATE comments SET body='New text',edited_at=")
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		expectedStatus int
+	}{
+		{name: "Author", userID: 7, expectedStatus: http.StatusOK},
+		{name: "Task Owner", userID: 1, expectedStatus: http.StatusOK},
+		{name: "Someone Else", userID: 8, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			loadComment()
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", tt.userID)
+			})
+			router.DELETE("/v1/tasks/:id/comments/:comment_id", DeleteComment)
+
+			req, err := createJSONRequest("DELETE", "/v1/tasks/42/comments/5", nil)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			// Comments are only ever soft deleted
+			all := strings.Join(*statements, "\n")
+			assert.NotContains(t, all, "DELETE FROM comments")
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, all, "UPDATE comments SET deleted_at=")
+			} else {
+				assert.NotContains(t, all, "UPDATE comments")
+			}
+		})
+	}
+}
+
+func TestRecordMentions(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	// alice (8) can see the task, bob (9) can't
+	onQuery("FROM users WHERE username IN", func(tx *gorm.DB) {
+		if users, ok := tx.Statement.Dest.(*[]models.User); ok {
+			alice := models.User{Username: "alice"}
+			alice.ID = 8
+			bob := models.User{Username: "bob"}
+			bob.ID = 9
+			*users = []models.User{alice, bob}
+		}
+	})
+	onQuery("tasks.created_by = 8", func(tx *gorm.DB) {
+		if count, ok := tx.Statement.Dest.(*int64); ok {
+			*count = 1
+			tx.RowsAffected = 1
+		}
+	})
+
+	comment := models.Comment{TaskID: 42, AuthorID: 7, Body: "@alice @bob can you take a look?"}
+	comment.ID = 5
+	assert.NoError(t, recordMentions(config.DB, &comment))
+
+	assert.Len(t, comment.Mentions, 1)
+	assert.Equal(t, uint(8), comment.Mentions[0].UserID)
+
+	all := strings.Join(*statements, "\n")
+	assert.Contains(t, all, "DELETE FROM comment_mentions WHERE comment_id = 5")
+	assert.Contains(t, all, "SELECT * FROM users WHERE username IN ('alice','bob')")
+	assert.Contains(t, all, "INSERT INTO comment_mentions (comment_id,user_id,created_at) VALUES (5,8,")
+	assert.NotContains(t, all, "VALUES (5,9,")
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/depgraph"
+	"task-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Dependencies reachable from a task by following "blocked by" links
+const upstreamDependencies = `WITH RECURSIVE edges AS (
+	SELECT task_id, blocked_by_id FROM task_dependencies WHERE task_id = ?
+	UNION
+	SELECT d.task_id, d.blocked_by_id FROM task_dependencies d JOIN edges e ON d.task_id = e.blocked_by_id
+) SELECT task_id AS task, blocked_by_id AS blocked_by FROM edges`
+
+// Dependencies reachable from a task by following "blocks" links
+const downstreamDependencies = `WITH RECURSIVE edges AS (
+	SELECT task_id, blocked_by_id FROM task_dependencies WHERE blocked_by_id = ?
+	UNION
+	SELECT d.task_id, d.blocked_by_id FROM task_dependencies d JOIN edges e ON d.blocked_by_id = e.task_id
+) SELECT task_id AS task, blocked_by_id AS blocked_by FROM edges`
+
+var errDependencyCycle = errors.New("Dependency would create a cycle")
+
+type blockedError struct {
+	Blockers []uint
+}
+
+func (e *blockedError) Error() string {
+	return fmt.Sprintf("Task is blocked by %d open task(s)", len(e.Blockers))
+}
+
+// checkBlockers fails with a blockedError while any blocker of the task is open.
+func checkBlockers(tx *gorm.DB, task *models.Task) error {
+	var blockers []uint
+	err := tx.Model(&models.TaskDependency{}).
+		Joins("JOIN tasks ON tasks.id = task_dependencies.blocked_by_id AND tasks.deleted_at IS NULL").
+		Where("task_dependencies.task_id = ? AND tasks.status NOT IN ?", task.ID, models.ClosedTaskStatuses).
+		Pluck("task_dependencies.blocked_by_id", &blockers).Error
+	if err != nil {
+		return err
+	}
+
+	if len(blockers) > 0 {
+		return &blockedError{Blockers: blockers}
+	}
+	return nil
+}
+
+func linkTasks(tx *gorm.DB, task, blocker models.Task, userID uint) error {
+	var edges []depgraph.Edge
+	if err := tx.Raw(upstreamDependencies, blocker.ID).Find(&edges).Error; err != nil {
+		return err
+	}
+
+	if depgraph.New(edges).WouldCycle(task.ID, blocker.ID) {
+		return errDependencyCycle
+	}
+
+	dependency := models.TaskDependency{TaskID: task.ID, BlockedByID: blocker.ID, CreatedBy: userID}
+	return tx.Where("task_id = ? AND blocked_by_id = ?", task.ID, blocker.ID).FirstOrCreate(&dependency).Error
+}
+
+func AddDependency(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		BlockedBy uint `json:"blocked_by"`
+		Blocks    uint `json:"blocks"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil || (body.BlockedBy == 0) == (body.Blocks == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either blocked_by or blocks"})
+		return
+	}
+
+	// The blocked task is the one being changed, the blocker only has to be visible
+	blockedID, blockerID := c.Param("id"), fmt.Sprint(body.BlockedBy)
+	if body.Blocks != 0 {
+		blockedID, blockerID = fmt.Sprint(body.Blocks), c.Param("id")
+	}
+
+	task, ok := findTask(c, blockedID, accessWrite)
+	if !ok {
+		return
+	}
+
+	blocker, ok := findTask(c, blockerID, accessRead)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialise dependency changes so two concurrent links can't form a cycle
+		if err := tx.Exec("LOCK TABLE task_dependencies IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		return linkTasks(tx, task, blocker, userID)
+	})
+
+	if errors.Is(err, errDependencyCycle) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error adding dependency",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Dependency added successfully",
+	})
+}
+
+func RemoveDependency(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	result := config.DB.Where("task_id = ? AND blocked_by_id = ?", task.ID, c.Param("blocker_id")).Delete(&models.TaskDependency{})
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error removing dependency",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Dependency not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Dependency removed successfully",
+	})
+}
+
+type graphNode struct {
+	ID       uint                `json:"id"`
+	Title    string              `json:"title"`
+	Status   models.TaskStatus   `json:"status"`
+	Priority models.TaskPriority `json:"priority"`
+}
+
+// GetDependencyGraph returns every task the task waits on and every task
+// waiting on it, transitively. Tasks the user can't see are left out.
+func GetDependencyGraph(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	var upstream, downstream []depgraph.Edge
+	err := config.DB.Raw(upstreamDependencies, task.ID).Scan(&upstream).Error
+	if err == nil {
+		err = config.DB.Raw(downstreamDependencies, task.ID).Scan(&downstream).Error
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't load dependencies",
+		})
+		return
+	}
+
+	ids := []uint{task.ID}
+	for _, edge := range append(upstream, downstream...) {
+		ids = append(ids, edge.Task, edge.BlockedBy)
+	}
+
+	var nodes []graphNode
+	err = config.DB.Model(&models.Task{}).
+		Scopes(visibleTasks(userID)).
+		Where("tasks.id IN ?", ids).
+		Order("tasks.id").
+		Find(&nodes).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't load dependencies",
+		})
+		return
+	}
+
+	visible := map[uint]bool{}
+	for _, node := range nodes {
+		visible[node.ID] = true
+	}
+
+	edges := []depgraph.Edge{}
+	for _, edge := range append(upstream, downstream...) {
+		if visible[edge.Task] && visible[edge.BlockedBy] {
+			edges = append(edges, edge)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"root":  task.ID,
+		"nodes": nodes,
+		"edges": edges,
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/internal/depgraph"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCompletingBlockedTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		blockers       []uint
+		expectedStatus int
+	}{
+		{name: "Open Blockers", blockers: []uint{4, 6}, expectedStatus: http.StatusConflict},
+		{name: "No Open Blockers", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+				if task, ok := tx.Statement.Dest.(*models.Task); ok {
+					task.Status = models.StatusOngoing
+				}
+			})
+			onQuery("FROM task_dependencies", func(tx *gorm.DB) {
+				if blockers, ok := tx.Statement.Dest.(*[]uint); ok {
+					*blockers = tt.blockers
+				}
+			})
+
+			// A dry run loads tasks created by user 0, so the caller owns them
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(0))
+			})
+			router.POST("/v1/tasks/:id/transition", TransitionTask)
+
+			req, err := createJSONRequest("POST", "/v1/tasks/42/transition", map[string]interface{}{"status": "completed"})
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			assert.Contains(t, all, "WHERE task_dependencies.task_id = 1 AND tasks.status NOT IN ('completed','cancelled')")
+			if tt.expectedStatus == http.StatusConflict {
+				var response struct {
+					Blockers []uint `json:"blockers"`
+				}
+				assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				assert.Equal(t, tt.blockers, response.Blockers)
+				assert.NotContains(t, all, "UPDATE tasks SET status=")
+			}
+		})
+	}
+}
+
+func TestAddDependency(t *testing.T) {
+	tests := []struct {
+		name           string
+		upstream       []depgraph.Edge
+		expectedStatus int
+	}{
+		// Task 2 already waits on task 1, so task 1 can't wait on task 2
+		{name: "Cycle", upstream: []depgraph.Edge{{Task: 2, BlockedBy: 1}}, expectedStatus: http.StatusConflict},
+		{name: "No Cycle", upstream: []depgraph.Edge{{Task: 2, BlockedBy: 3}}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			loadTaskTree(nil)
+			onQuery("WITH RECURSIVE edges", func(tx *gorm.DB) {
+				if edges, ok := tx.Statement.Dest.(*[]depgraph.Edge); ok {
+					*edges = tt.upstream
+				}
+			})
+
+			// A dry run loads tasks created by user 0, so the caller owns them
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(0))
+			})
+			router.POST("/v1/tasks/:id/dependencies", AddDependency)
+
+			req, err := createJSONRequest("POST", "/v1/tasks/1/dependencies", map[string]interface{}{"blocked_by": 2})
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			if tt.expectedStatus == http.StatusConflict {
+				assert.Contains(t, recorder.Body.String(), errDependencyCycle.Error())
+				assert.NotContains(t, all, "INSERT INTO task_dependencies")
+			} else {
+				assert.Contains(t, all, "INSERT INTO task_dependencies (task_id,blocked_by_id,created_by")
+			}
+		})
+	}
+}
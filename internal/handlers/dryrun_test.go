@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/revocation"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunDriver only supports transactions, which are all a dry-run gorm
+// connection ever asks of the database.
+type dryRunDriver struct{}
+
+type dryRunConn struct{}
+
+type dryRunTx struct{}
+
+func (dryRunDriver) Open(string) (driver.Conn, error) { return dryRunConn{}, nil }
+
+func (dryRunConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("dry run") }
+func (dryRunConn) Close() error                        { return nil }
+func (dryRunConn) Begin() (driver.Tx, error)           { return dryRunTx{}, nil }
+
+func (dryRunTx) Commit() error   { return nil }
+func (dryRunTx) Rollback() error { return nil }
+
+func init() {
+	sql.Register("dryrun", dryRunDriver{})
+}
+
+// fillPrimaryKey gives records loaded one at a time the ID 1, since a dry run
+// loads nothing and gorm refuses to update or delete records without an ID.
+func fillPrimaryKey(tx *gorm.DB) {
+	value := tx.Statement.ReflectValue
+	schema := tx.Statement.Schema
+	if schema == nil || schema.PrioritizedPrimaryField == nil || value.Kind() != reflect.Struct {
+		return
+	}
+
+	primaryKey := schema.PrioritizedPrimaryField
+	if _, zero := primaryKey.ValueOf(tx.Statement.Context, value); zero {
+		primaryKey.Set(tx.Statement.Context, value, 1)
+	}
+}
+
+// explain returns the SQL of the statement with its values filled in.
+func explain(tx *gorm.DB) string {
+	sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	return strings.ReplaceAll(sql, `"`, "")
+}
+
+// matchRow reports that updates and deletes matched one row, as they do when
+// nobody else changed the record first.
+func matchRow(tx *gorm.DB) {
+	tx.RowsAffected = 1
+}
+
+// setupDryRunDB points config.DB at a dry-run connection that never touches
+// a database and returns the SQL of every statement built against it. Updates
+// and deletes report one affected row, see matchNoRows for the other case.
+func setupDryRunDB() (*[]string, error) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: "dryrun"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statements := []string{}
+	capture := func(tx *gorm.DB) {
+		statements = append(statements, explain(tx))
+	}
+	db.Callback().Query().After("gorm:query").Register("test:capture_query", capture)
+	db.Callback().Query().After("test:capture_query").Register("test:primary_key", fillPrimaryKey)
+	db.Callback().Row().After("gorm:row").Register("test:capture_row", capture)
+	db.Callback().Update().After("gorm:update").Register("test:capture_update", capture)
+	db.Callback().Update().After("test:capture_update").Register("test:rows_update", matchRow)
+	db.Callback().Delete().After("gorm:delete").Register("test:capture_delete", capture)
+	db.Callback().Delete().After("test:capture_delete").Register("test:rows_delete", matchRow)
+	db.Callback().Create().After("gorm:create").Register("test:capture_create", capture)
+
+	config.DB = db
+	config.Revocations = &revocation.Store{DB: db}
+	return &statements, nil
+}
+
+// matchNoRows makes the updates and deletes whose SQL contains the text report
+// no affected rows, as when a concurrent request changed the record first.
+func matchNoRows(text string) {
+	noRows := func(tx *gorm.DB) {
+		if strings.Contains(explain(tx), text) {
+			tx.RowsAffected = 0
+		}
+	}
+	config.DB.Callback().Update().After("test:rows_update").Register("test:no_rows_update", noRows)
+	config.DB.Callback().Delete().After("test:rows_delete").Register("test:no_rows_delete", noRows)
+}
+
+// onQuery runs fn after every query whose SQL contains the text, so a test can
+// shape what a dry run loads: fill in tx.Statement.Dest, or add
+// gorm.ErrRecordNotFound to report that nothing matched.
+func onQuery(text string, fn func(tx *gorm.DB)) {
+	queries := config.DB.Callback().Query()
+
+	name := "test:on_query"
+	for i := 1; queries.Get(name) != nil; i++ {
+		name = fmt.Sprintf("test:on_query_%d", i)
+	}
+
+	queries.After("test:primary_key").Register(name, func(tx *gorm.DB) {
+		if strings.Contains(explain(tx), text) {
+			fn(tx)
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxLabelName = 50
+
+var labelColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// visibleLabels limits a label query to the user's own labels and the labels
+// of their teams.
+func visibleLabels(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("labels.owner_id = ? OR labels.team_id IN (?)", userID, memberTeams(db, userID, models.RoleViewer))
+	}
+}
+
+// canManageLabel allows changing a label to its owner and to members of its team.
+func canManageLabel(label models.Label, userID uint) bool {
+	if label.OwnerID == userID {
+		return true
+	}
+	return label.TeamID != nil && teamRole(userID, *label.TeamID).AtLeast(models.RoleMember)
+}
+
+func validateLabel(name, color string) error {
+	if name == "" || len(name) > maxLabelName {
+		return fmt.Errorf("Label name must be 1 to %d characters", maxLabelName)
+	}
+	if color != "" && !labelColor.MatchString(color) {
+		return fmt.Errorf("Label color must look like #1f6feb")
+	}
+	return nil
+}
+
+func findLabel(c *gin.Context, labelID string, manage bool) (models.Label, bool) {
+	userID := c.GetUint("user_id")
+
+	var label models.Label
+	err := config.DB.Scopes(visibleLabels(userID)).First(&label, "labels.id = ?", labelID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Label not found",
+		})
+		return label, false
+	}
+
+	if manage && !canManageLabel(label, userID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You don't have permission to modify this label",
+		})
+		return label, false
+	}
+
+	return label, true
+}
+
+func CreateLabel(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		Name   string `json:"name" binding:"required"`
+		Color  string `json:"color"`
+		TeamID *uint  `json:"team_id"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	if body.TeamID != nil && !teamRole(userID, *body.TeamID).AtLeast(models.RoleMember) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add labels to this team"})
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if err := validateLabel(body.Name, body.Color); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	newLabel := models.Label{Name: body.Name, Color: body.Color, OwnerID: userID, TeamID: body.TeamID}
+	result := config.DB.Create(&newLabel)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error creating label, the name may already be taken",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Label created successfully",
+		"label":   newLabel,
+	})
+}
+
+func GetLabels(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var labels []models.Label
+	err := config.DB.Scopes(visibleLabels(userID)).Order("labels.name").Find(&labels).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find labels",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, labels)
+}
+
+func UpdateLabel(c *gin.Context) {
+	label, ok := findLabel(c, c.Param("id"), true)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fields are empty",
+		})
+		return
+	}
+
+	if body.Name != "" {
+		label.Name = strings.TrimSpace(body.Name)
+	}
+
+	if body.Color != "" {
+		label.Color = body.Color
+	}
+
+	if err := validateLabel(label.Name, label.Color); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Tasks show their labels, so the tasks carrying it change too
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&label).Error; err != nil {
+			return err
+		}
+		return touchTasks(tx, "id IN (SELECT task_id FROM task_labels WHERE label_id = ?)", label.ID)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update label, the name may already be taken",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Label updated successfully",
+		"label":   label,
+	})
+}
+
+func DeleteLabel(c *gin.Context) {
+	label, ok := findLabel(c, c.Param("id"), true)
+	if !ok {
+		return
+	}
+
+	// Labels are removed for good so the name can be used again
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := touchTasks(tx, "id IN (SELECT task_id FROM task_labels WHERE label_id = ?)", label.ID); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_labels WHERE label_id = ?", label.ID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&label).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error deleting label",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Label deleted successfully",
+	})
+}
+
+func AttachLabel(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	label, ok := findLabel(c, c.Param("label_id"), false)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&task).Association("Labels").Append(&label); err != nil {
+			return err
+		}
+		return touchTask(tx, &task)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error attaching label",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Label attached successfully",
+	})
+}
+
+func DetachLabel(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	// Shared tasks may carry labels of other users, which editors can still remove
+	var label models.Label
+	err := config.DB.First(&label, "id = ?", c.Param("label_id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Label not found",
+		})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&task).Association("Labels").Delete(&label); err != nil {
+			return err
+		}
+		return touchTask(tx, &task)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error detaching label",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Label detached successfully",
+	})
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLabel(t *testing.T) {
+	tests := []struct {
+		name        string
+		labelName   string
+		color       string
+		expectError bool
+	}{
+		{name: "Valid Label", labelName: "bug", color: "#d73a4a"},
+		{name: "Default Color", labelName: "backend", color: ""},
+		{name: "Empty Name", labelName: "", color: "#d73a4a", expectError: true},
+		{name: "Long Name", labelName: strings.Repeat("a", maxLabelName+1), expectError: true},
+		{name: "Named Color", labelName: "bug", color: "red", expectError: true},
+		{name: "Short Hex Color", labelName: "bug", color: "#fff", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLabel(tt.labelName, tt.color)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"task-manager/config"
+	"task-manager/internal/mailer"
+	"task-manager/internal/models"
+	"task-manager/internal/tokens"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	errResetInvalid   = errors.New("Reset token is invalid or expired")
+	errResetThrottled = errors.New("A reset email was sent moments ago")
+)
+
+// resetQueueSize bounds the reset emails waiting to be sent.
+const resetQueueSize = 100
+
+// resetQueue holds the emails ForgotPassword was asked to send a reset to,
+// for SendPasswordResets to work through.
+var resetQueue = make(chan string, resetQueueSize)
+
+// passwordResetMessage is the email carrying a reset token.
+func passwordResetMessage(user models.User, secret string, expiresAt time.Time) mailer.Message {
+	instructions := "use this token to choose a new password"
+	target := secret
+
+	if config.PasswordResetURL != "" {
+		if link, err := url.Parse(config.PasswordResetURL); err == nil {
+			query := link.Query()
+			query.Set("token", secret)
+			link.RawQuery = query.Encode()
+
+			instructions = "open this link to choose a new password"
+			target = link.String()
+		}
+	}
+
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"someone asked to reset the password of your account. If it was you, %s:\n\n"+
+			"%s\n\n"+
+			"It works once and expires at %s. If you didn't ask for it, you can ignore this email.\n",
+			user.Username, instructions, target, expiresAt.UTC().Format(time.RFC1123)),
+	}
+}
+
+// sendPasswordReset stores a new reset token for the user and emails it,
+// unless the user was sent one within config.PasswordResetInterval. Links sent
+// before only stop working once the new one is on its way.
+func sendPasswordReset(user models.User, now time.Time) error {
+	var recent int64
+	err := config.DB.Model(&models.PasswordReset{}).
+		Where("user_id = ? AND created_at > ?", user.ID, now.Add(-config.PasswordResetInterval)).
+		Count(&recent).Error
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		return errResetThrottled
+	}
+
+	secret, err := tokens.Generate("pr_")
+	if err != nil {
+		return err
+	}
+
+	reset := models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: tokens.Hash(secret),
+		ExpiresAt: now.Add(config.PasswordResetTTL),
+		CreatedAt: now,
+	}
+	if err := config.DB.Create(&reset).Error; err != nil {
+		return err
+	}
+
+	if err := config.Mailer.Send(passwordResetMessage(user, secret, reset.ExpiresAt)); err != nil {
+		// Nobody got the token, so the earlier ones keep working
+		if err := config.DB.Where("token_hash = ?", reset.TokenHash).Delete(&models.PasswordReset{}).Error; err != nil {
+			log.Println("❌ Failed to delete unsent password reset:", err)
+		}
+		return err
+	}
+
+	return config.DB.Model(&models.PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL AND token_hash <> ?", user.ID, reset.TokenHash).
+		Update("used_at", now).Error
+}
+
+// sendQueuedReset sends a reset to the account with the email, if any.
+func sendQueuedReset(email string, now time.Time) {
+	var user models.User
+	if err := config.DB.First(&user, "email = ?", email).Error; err != nil {
+		return
+	}
+
+	err := sendPasswordReset(user, now)
+	if err != nil && err != errResetThrottled {
+		log.Println("❌ Failed to send password reset:", err)
+	}
+}
+
+// SendPasswordResets sends the reset emails queued by ForgotPassword, one at
+// a time, until the context is done.
+func SendPasswordResets(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case email := <-resetQueue:
+			sendQueuedReset(email, time.Now())
+		}
+	}
+}
+
+func ForgotPassword(c *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fields are empty",
+		})
+		return
+	}
+
+	// The email is looked up and sent by SendPasswordResets, so neither the
+	// response nor how long it takes tells whether the email belongs to an
+	// account. When the queue is full the request is dropped.
+	select {
+	case resetQueue <- body.Email:
+	default:
+		log.Println("❌ Password reset queue is full, dropping a request")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If the email belongs to an account, a reset token was sent to it",
+	})
+}
+
+func ResetPassword(c *gin.Context) {
+	var body struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fields are empty",
+		})
+		return
+	}
+
+	now := time.Now()
+
+	var reset models.PasswordReset
+	err := config.DB.First(&reset, "token_hash = ?", tokens.Hash(body.Token)).Error
+	if err != nil || reset.UsedAt != nil || !now.Before(reset.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errResetInvalid.Error(),
+		})
+		return
+	}
+
+	// Hash the password
+	hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), 10)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error hashing the password",
+		})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Of two requests racing with the same token only one wins
+		used := tx.Model(&reset).Where("used_at IS NULL").Update("used_at", now)
+		if used.Error != nil {
+			return used.Error
+		}
+		if used.RowsAffected == 0 {
+			return errResetInvalid
+		}
+
+		// Whoever knew the old password is logged out everywhere, including
+		// the tokens they may have created with it
+		err := tx.Model(&models.User{}).Where("id = ?", reset.UserID).Updates(map[string]interface{}{
+			"password":            string(hash),
+			"sessions_revoked_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ?", reset.UserID).Delete(&models.PersonalAccessToken{}).Error
+		if err != nil {
+			return err
+		}
+		return revokeRefreshTokens(tx, now, "user_id = ?", reset.UserID)
+	})
+
+	if err == errResetInvalid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reset password",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
+	})
+}
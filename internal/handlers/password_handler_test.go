@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/mailer"
+	"task-manager/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// recordingMailer keeps the messages it is asked to send.
+type recordingMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return m.err
+}
+
+func TestPasswordResetMessage(t *testing.T) {
+	defer func(url string) { config.PasswordResetURL = url }(config.PasswordResetURL)
+
+	user := models.User{Username: "john", Email: "john@example.com"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{name: "Token Only", url: "", expected: "\n\npr_abc\n\n"},
+		{name: "Link", url: "https://app.example.com/reset", expected: "https://app.example.com/reset?token=pr_abc"},
+		{name: "Link With Query", url: "https://app.example.com/reset?lang=en", expected: "https://app.example.com/reset?lang=en&token=pr_abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.PasswordResetURL = tt.url
+
+			msg := passwordResetMessage(user, "pr_abc", expiresAt)
+			assert.Equal(t, "john@example.com", msg.To)
+			assert.Contains(t, msg.Body, tt.expected)
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	defer func(m mailer.Mailer) { config.Mailer = m }(config.Mailer)
+
+	tests := []struct {
+		name           string
+		body           string
+		sendErr        error
+		recentReset    bool
+		expectedStatus int
+		expectedMail   bool
+		shouldContain  []string
+		notContain     []string
+	}{
+		{
+			name:           "Sends Token",
+			body:           `{"email":"john@example.com"}`,
+			expectedStatus: http.StatusOK,
+			expectedMail:   true,
+			shouldContain: []string{
+				"SELECT count(*) FROM password_resets WHERE user_id = 1 AND created_at >",
+				"INSERT INTO password_resets (user_id,token_hash,expires_at,used_at,created_at) VALUES (1,",
+				"UPDATE password_resets SET used_at=",
+				"WHERE user_id = 1 AND used_at IS NULL AND token_hash <> ",
+			},
+		},
+		{
+			// Failing to send mail doesn't show in the response either, and
+			// the links sent before keep working
+			name:           "Mailer Fails",
+			body:           `{"email":"john@example.com"}`,
+			sendErr:        errors.New("connection refused"),
+			expectedStatus: http.StatusOK,
+			expectedMail:   true,
+			shouldContain:  []string{"DELETE FROM password_resets WHERE token_hash ="},
+			notContain:     []string{"UPDATE password_resets SET used_at="},
+		},
+		{
+			name:           "Sent Moments Ago",
+			body:           `{"email":"john@example.com"}`,
+			recentReset:    true,
+			expectedStatus: http.StatusOK,
+			notContain:     []string{"INSERT INTO password_resets", "UPDATE password_resets SET used_at="},
+		},
+		{
+			name:           "Missing Email",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			if tt.recentReset {
+				onQuery("FROM password_resets WHERE user_id", func(tx *gorm.DB) {
+					if count, ok := tx.Statement.Dest.(*int64); ok {
+						*count = 1
+						tx.RowsAffected = 1
+					}
+				})
+			}
+
+			mail := &recordingMailer{err: tt.sendErr}
+			config.Mailer = mail
+
+			router := setupTestRouter()
+			router.POST("/user/password/forgot", ForgotPassword)
+
+			req, err := http.NewRequest("POST", "/user/password/forgot", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			// The request only queues the email, nothing is sent before the
+			// response
+			assert.Empty(t, mail.sent)
+			select {
+			case email := <-resetQueue:
+				sendQueuedReset(email, time.Now())
+			default:
+			}
+
+			if tt.expectedMail {
+				assert.Len(t, mail.sent, 1)
+				assert.Contains(t, mail.sent[0].Body, "pr_")
+				assert.NotContains(t, recorder.Body.String(), "pr_")
+			} else {
+				assert.Empty(t, mail.sent)
+			}
+
+			all := strings.Join(*statements, "\n")
+			for _, expected := range tt.shouldContain {
+				assert.Contains(t, all, expected)
+			}
+			for _, unexpected := range tt.notContain {
+				assert.NotContains(t, all, unexpected)
+			}
+		})
+	}
+}
+
+func TestForgotPasswordDropsRequestsWhenQueueIsFull(t *testing.T) {
+	defer func(queue chan string) { resetQueue = queue }(resetQueue)
+	resetQueue = make(chan string, 1)
+	resetQueue <- "jane@example.com"
+
+	router := setupTestRouter()
+	router.POST("/user/password/forgot", ForgotPassword)
+
+	req, err := http.NewRequest("POST", "/user/password/forgot", strings.NewReader(`{"email":"john@example.com"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	// The response is the same, it just doesn't wait for room in the queue
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "jane@example.com", <-resetQueue)
+	assert.Empty(t, resetQueue)
+}
+
+func TestResetPassword(t *testing.T) {
+	used := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name           string
+		body           string
+		stored         *models.PasswordReset
+		noRows         string
+		expectedStatus int
+		shouldContain  []string
+	}{
+		{
+			name:           "Resets Password",
+			body:           `{"token":"pr_abc","password":"secret"}`,
+			stored:         &models.PasswordReset{UserID: 7, ExpiresAt: time.Now().Add(time.Hour)},
+			expectedStatus: http.StatusOK,
+			shouldContain: []string{
+				"UPDATE password_resets SET used_at=",
+				"WHERE used_at IS NULL AND id = 1",
+				"UPDATE users SET password=",
+				"sessions_revoked_at=",
+				"WHERE id = 7",
+				"UPDATE refresh_tokens SET revoked_at=",
+				"WHERE revoked_at IS NULL AND user_id = 7",
+			},
+		},
+		{
+			name:           "Used Token",
+			body:           `{"token":"pr_abc","password":"secret"}`,
+			stored:         &models.PasswordReset{UserID: 7, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &used},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			// Another request used the token after it was loaded
+			name:           "Token Used Concurrently",
+			body:           `{"token":"pr_abc","password":"secret"}`,
+			stored:         &models.PasswordReset{UserID: 7, ExpiresAt: time.Now().Add(time.Hour)},
+			noRows:         "UPDATE password_resets SET used_at=",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			// A dry run loads a token that expired long ago
+			name:           "Expired Token",
+			body:           `{"token":"pr_abc","password":"secret"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing Password",
+			body:           `{"token":"pr_abc"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			if tt.stored != nil {
+				stored := *tt.stored
+				config.DB.Callback().Query().After("test:primary_key").Register("test:password_reset", func(tx *gorm.DB) {
+					if reset, ok := tx.Statement.Dest.(*models.PasswordReset); ok {
+						stored.ID = reset.ID
+						*reset = stored
+					}
+				})
+			}
+
+			if tt.noRows != "" {
+				matchNoRows(tt.noRows)
+			}
+
+			router := setupTestRouter()
+			router.POST("/user/password/reset", ResetPassword)
+
+			req, err := http.NewRequest("POST", "/user/password/reset", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			for _, expected := range tt.shouldContain {
+				assert.Contains(t, all, expected)
+			}
+		})
+	}
+}
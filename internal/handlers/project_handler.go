@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// visibleProjects limits a project query to the user's own projects and the
+// projects of their teams.
+func visibleProjects(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("projects.owner_id = ? OR projects.team_id IN (?)", userID, memberTeams(db, userID, models.RoleViewer))
+	}
+}
+
+// findProject loads a project the user can see and checks that their role in
+// it is at least the given one.
+func findProject(c *gin.Context, projectID string, role models.TeamRole) (models.Project, bool) {
+	userID := c.GetUint("user_id")
+
+	var project models.Project
+	err := config.DB.Scopes(visibleProjects(userID)).First(&project, "projects.id = ?", projectID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Project not found",
+		})
+		return project, false
+	}
+
+	// Every visible project is readable, so only stronger roles need a lookup
+	if role != models.RoleViewer && !projectRole(userID, project).AtLeast(role) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You don't have permission to modify this project",
+		})
+		return project, false
+	}
+
+	return project, true
+}
+
+// checkProject makes sure the user may file tasks under the project.
+func checkProject(userID uint, projectID uint) error {
+	var project models.Project
+	err := config.DB.Scopes(visibleProjects(userID)).First(&project, "projects.id = ?", projectID).Error
+	if err != nil {
+		return fmt.Errorf("Project not found")
+	}
+
+	if !projectRole(userID, project).AtLeast(models.RoleMember) {
+		return fmt.Errorf("You don't have permission to add tasks to this project")
+	}
+
+	if project.ArchivedAt != nil {
+		return fmt.Errorf("Project is archived")
+	}
+
+	return nil
+}
+
+// checkProjectChange makes sure the user may move the task to the project, or
+// take it out of its project when projectID is 0. The project decides which
+// team sees the task, so moving it is up to those who can manage it.
+func checkProjectChange(task models.Task, userID uint, projectID uint) error {
+	if !canManageTask(task, userID) {
+		return fmt.Errorf("You don't have permission to move this task to another project")
+	}
+	if projectID == 0 {
+		return nil
+	}
+	return checkProject(userID, projectID)
+}
+
+func CreateProject(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		TeamID      *uint  `json:"team_id"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	if body.TeamID != nil && !teamRole(userID, *body.TeamID).AtLeast(models.RoleMember) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add projects to this team"})
+		return
+	}
+
+	newProject := models.Project{Name: body.Name, Description: body.Description, OwnerID: userID, TeamID: body.TeamID}
+	result := config.DB.Create(&newProject)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error creating project",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project created successfully",
+		"project": newProject,
+	})
+}
+
+func GetProjects(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	query := config.DB.Scopes(visibleProjects(userID))
+	if c.Query("archived") != "true" {
+		query = query.Where("projects.archived_at IS NULL")
+	}
+
+	var projects []models.Project
+	err := query.Order("projects.name").Find(&projects).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find projects",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+func GetProject(c *gin.Context) {
+	project, ok := findProject(c, c.Param("id"), models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+func UpdateProject(c *gin.Context) {
+	project, ok := findProject(c, c.Param("id"), models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Name        string  `json:"name"`
+		Description *string `json:"description"`
+	}
+
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fields are empty",
+		})
+		return
+	}
+
+	if body.Name != "" {
+		project.Name = body.Name
+	}
+
+	if body.Description != nil {
+		project.Description = *body.Description
+	}
+
+	err := config.DB.Save(&project).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update project",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project updated successfully",
+		"project": project,
+	})
+}
+
+func DeleteProject(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	project, ok := findProject(c, c.Param("id"), models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	// Tasks outlive their project and go back to the unfiled pile
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var tasks []models.Task
+		if err := tx.Unscoped().Where("project_id = ?", project.ID).Find(&tasks).Error; err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Model(&models.Task{}).Where("project_id = ?", project.ID).Updates(map[string]interface{}{
+			"project_id": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			before := task
+			task.ProjectID = nil
+			task.Version++
+
+			if err := recordChanges(tx, before, task, userID); err != nil {
+				return err
+			}
+
+			// Members who only saw the task through the team lose it
+			if err := pruneAssignees(tx, &task); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&project).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error deleting project",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project deleted successfully",
+	})
+}
+
+func setProjectArchived(c *gin.Context, archived bool) {
+	project, ok := findProject(c, c.Param("id"), models.RoleAdmin)
+	if !ok {
+		return
+	}
+
+	project.ArchivedAt = nil
+	if archived {
+		now := time.Now()
+		project.ArchivedAt = &now
+	}
+
+	err := config.DB.Model(&project).Update("archived_at", project.ArchivedAt).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update project",
+		})
+		return
+	}
+
+	message := "Project unarchived successfully"
+	if archived {
+		message = "Project archived successfully"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"project": project,
+	})
+}
+
+func ArchiveProject(c *gin.Context) {
+	setProjectArchived(c, true)
+}
+
+func UnarchiveProject(c *gin.Context) {
+	setProjectArchived(c, false)
+}
+
+func GetProjectTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	project, ok := findProject(c, c.Param("id"), models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	query, err := parseTaskQuery(c.Request.URL.Query(), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	query.projectID = &project.ID
+
+	var tasks []models.Task
+	err = config.DB.Scopes(visibleTasks(userID), query.scope).Preload("Labels").Preload("Assignees").Find(&tasks).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find tasks",
+		})
+		return
+	}
+
+	tasks, nextCursor := query.page(tasks)
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":       tasks,
+		"next_cursor": nextCursor,
+	})
+}
+
+// statusCount is the number of a project's tasks in one status.
+type statusCount struct {
+	Status models.TaskStatus
+	Count  int64
+}
+
+func GetProjectStats(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	project, ok := findProject(c, c.Param("id"), models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	var rows []statusCount
+	err := config.DB.Model(&models.Task{}).
+		Scopes(visibleTasks(userID)).
+		Where("tasks.project_id = ?", project.ID).
+		Select("tasks.status, COUNT(*) AS count").
+		Group("tasks.status").
+		Find(&rows).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't count tasks",
+		})
+		return
+	}
+
+	counts := map[models.TaskStatus]int64{}
+	for _, status := range models.TaskStatuses {
+		counts[status] = 0
+	}
+
+	var total int64
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+		total += row.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"project": project,
+		"counts":  counts,
+		"total":   total,
+	})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/internal/models"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCheckProject(t *testing.T) {
+	teamID := uint(3)
+	archivedAt := time.Now()
+
+	tests := []struct {
+		name        string
+		missing     bool
+		teamID      *uint
+		role        models.TeamRole
+		archivedAt  *time.Time
+		expectedErr string
+	}{
+		{name: "Personal Project", expectedErr: ""},
+		{name: "Team Member", teamID: &teamID, role: models.RoleMember, expectedErr: ""},
+		{name: "Not Visible", missing: true, expectedErr: "Project not found"},
+		{name: "Team Viewer", teamID: &teamID, role: models.RoleViewer, expectedErr: "You don't have permission to add tasks to this project"},
+		{name: "Archived", archivedAt: &archivedAt, expectedErr: "Project is archived"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			onQuery("FROM projects WHERE", func(tx *gorm.DB) {
+				project, ok := tx.Statement.Dest.(*models.Project)
+				if !ok {
+					return
+				}
+				if tt.missing {
+					tx.AddError(gorm.ErrRecordNotFound)
+					return
+				}
+				project.OwnerID = 7
+				project.TeamID = tt.teamID
+				project.ArchivedAt = tt.archivedAt
+			})
+			onQuery("team_id = 3 AND user_id = 7", func(tx *gorm.DB) {
+				if membership, ok := tx.Statement.Dest.(*models.Membership); ok {
+					*membership = models.Membership{TeamID: 3, UserID: 7, Role: tt.role}
+				}
+			})
+
+			err = checkProject(7, 1)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestDeleteProject(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           models.TeamRole
+		expectedStatus int
+	}{
+		{name: "Team Admin", role: models.RoleAdmin, expectedStatus: http.StatusOK},
+		{name: "Team Member", role: models.RoleMember, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			// Project 1 of team 3 holds task 9, assigned to user 8 through the team
+			onQuery("FROM projects WHERE", func(tx *gorm.DB) {
+				if project, ok := tx.Statement.Dest.(*models.Project); ok {
+					teamID := uint(3)
+					project.TeamID = &teamID
+				}
+			})
+			onQuery("team_id = 3 AND user_id = 7", func(tx *gorm.DB) {
+				if membership, ok := tx.Statement.Dest.(*models.Membership); ok {
+					*membership = models.Membership{TeamID: 3, UserID: 7, Role: tt.role}
+				}
+			})
+			onQuery("FROM tasks WHERE project_id = 1", func(tx *gorm.DB) {
+				if tasks, ok := tx.Statement.Dest.(*[]models.Task); ok {
+					projectID := uint(1)
+					task := models.Task{CreatedBy: 7, ProjectID: &projectID, Version: 2}
+					task.ID = 9
+					*tasks = []models.Task{task}
+				}
+			})
+			onQuery("FROM task_assignees WHERE task_id = 9", func(tx *gorm.DB) {
+				if assignees, ok := tx.Statement.Dest.(*[]models.TaskAssignee); ok {
+					*assignees = []models.TaskAssignee{{ID: 5, TaskID: 9, UserID: 8}}
+				}
+			})
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+			})
+			router.DELETE("/v1/projects/:id", DeleteProject)
+
+			req, err := createJSONRequest("DELETE", "/v1/projects/1", nil)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			if tt.expectedStatus != http.StatusOK {
+				assert.NotContains(t, all, "UPDATE tasks")
+				assert.NotContains(t, all, "UPDATE projects SET deleted_at")
+				return
+			}
+
+			unfiled := strings.Index(all, "UPDATE tasks SET project_id=NULL,version=version + 1")
+			recorded := strings.Index(all, "INSERT INTO task_history (task_id,user_id,action,field,old_value,new_value,created_at) VALUES (9,7,'updated','project_id','1',''")
+			pruned := strings.Index(all, "DELETE FROM task_assignees WHERE task_assignees.id = 5")
+			deleted := strings.Index(all, "UPDATE projects SET deleted_at=")
+			assert.NotEqual(t, -1, unfiled)
+			assert.Less(t, unfiled, recorded)
+			assert.Less(t, recorded, pruned)
+			assert.Less(t, pruned, deleted)
+		})
+	}
+}
+
+func TestGetProjectStats(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	onQuery("GROUP BY tasks.status", func(tx *gorm.DB) {
+		if rows, ok := tx.Statement.Dest.(*[]statusCount); ok {
+			*rows = []statusCount{{Status: models.StatusCompleted, Count: 3}, {Status: models.StatusNew, Count: 2}}
+		}
+	})
+
+	// A dry run loads a personal project owned by user 0
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(0))
+	})
+	router.GET("/v1/projects/:id/stats", GetProjectStats)
+
+	req, err := createJSONRequest("GET", "/v1/projects/1/stats", nil)
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Counts map[models.TaskStatus]int64 `json:"counts"`
+		Total  int64                       `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+	// Every status is listed, even without tasks in it
+	assert.Len(t, response.Counts, len(models.TaskStatuses))
+	assert.Equal(t, int64(3), response.Counts[models.StatusCompleted])
+	assert.Equal(t, int64(2), response.Counts[models.StatusNew])
+	assert.Equal(t, int64(0), response.Counts[models.StatusOngoing])
+	assert.Equal(t, int64(5), response.Total)
+
+	// Only tasks the caller can see are counted
+	all := strings.Join(*statements, "\n")
+	assert.Contains(t, all, "WHERE tasks.project_id = 1 AND (tasks.created_by = 0 OR tasks.id IN (SELECT task_id FROM task_shares WHERE user_id = 0")
+}
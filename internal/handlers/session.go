@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"errors"
+	"net/http"
+	"os"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/tokens"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errRefreshInvalid = errors.New("Refresh token is invalid or expired")
+	errRefreshReused  = errors.New("Refresh token was already used, the session was revoked")
+)
+
+// tokenPair is what a login or refresh hands out.
+type tokenPair struct {
+	Access          string
+	AccessExpiresAt time.Time
+	Refresh         string
+	CSRF            string
+}
+
+// issueTokens signs a new access token and stores a new refresh token in the
+// family. The family ID goes into the access token as "sid", so logging out
+// can revoke the refresh tokens of the session too.
+func issueTokens(tx *gorm.DB, userID uint, family string, now time.Time) (tokenPair, error) {
+	jti, err := tokens.Generate("")
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	pair := tokenPair{
+		AccessExpiresAt: now.Add(config.AccessTokenTTL),
+		CSRF:            tokens.CSRF(os.Getenv("JWT_SECRET"), family),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userID,
+		"jti": jti,
+		"sid": family,
+		"iat": float64(now.UnixMicro()) / 1e6, // to the microsecond, see middlewares.issuedBy
+		"exp": pair.AccessExpiresAt.Unix(),
+	})
+
+	pair.Access, err = token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	pair.Refresh, err = tokens.Generate("rt_")
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	err = tx.Create(&models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: tokens.Hash(pair.Refresh),
+		ExpiresAt: now.Add(config.RefreshTokenTTL),
+	}).Error
+	return pair, err
+}
+
+// respond sets the token cookies and sends the tokens in the response body.
+// The CSRF token cookie is readable by scripts, which have to send it back
+// in the X-CSRF-Token header.
+func (p tokenPair) respond(c *gin.Context, message string) {
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie("jwt", p.Access, int(config.AccessTokenTTL.Seconds()), "", "", false, true)
+	c.SetCookie("refresh_token", p.Refresh, int(config.RefreshTokenTTL.Seconds()), "/user", "", false, true)
+	c.SetCookie("csrf_token", p.CSRF, int(config.RefreshTokenTTL.Seconds()), "", "", false, false)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       message,
+		"token":         p.Access,
+		"refresh_token": p.Refresh,
+		"csrf_token":    p.CSRF,
+		"expires_in":    int(config.AccessTokenTTL.Seconds()),
+	})
+}
+
+func clearTokenCookies(c *gin.Context) {
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie("jwt", "", -1, "", "", false, true)
+	c.SetCookie("refresh_token", "", -1, "/user", "", false, true)
+	c.SetCookie("csrf_token", "", -1, "", "", false, false)
+}
+
+// checkRefreshToken reports why a refresh token can't be exchanged.
+func checkRefreshToken(token models.RefreshToken, now time.Time) error {
+	switch {
+	case token.RevokedAt != nil || !now.Before(token.ExpiresAt):
+		return errRefreshInvalid
+	case token.UsedAt != nil:
+		return errRefreshReused
+	}
+	return nil
+}
+
+// revokeRefreshTokens revokes the refresh tokens matched by the conditions.
+func revokeRefreshTokens(db *gorm.DB, now time.Time, query string, args ...interface{}) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("revoked_at IS NULL").
+		Where(query, args...).
+		Update("revoked_at", now).Error
+}
+
+// RefreshToken exchanges a refresh token, from the body or the cookie, for a
+// new access token and refresh token. Each refresh token works once: using
+// one again means it was stolen, so the whole session is revoked.
+func RefreshToken(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// The body is optional when the cookie is sent
+	_ = c.ShouldBindJSON(&body)
+	fromCookie := false
+	if body.RefreshToken == "" {
+		body.RefreshToken, _ = c.Cookie("refresh_token")
+		fromCookie = body.RefreshToken != ""
+	}
+
+	if body.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Refresh token is missing",
+		})
+		return
+	}
+
+	now := time.Now()
+
+	var token models.RefreshToken
+	err := config.DB.First(&token, "token_hash = ?", tokens.Hash(body.RefreshToken)).Error
+	if err == nil {
+		err = checkRefreshToken(token, now)
+	}
+
+	// Like AuthMiddleware, cookies need the CSRF token of their session
+	csrf := tokens.CSRF(os.Getenv("JWT_SECRET"), token.FamilyID)
+	if err == nil && fromCookie && !hmac.Equal([]byte(c.GetHeader("X-CSRF-Token")), []byte(csrf)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "CSRF token is missing or invalid",
+		})
+		return
+	}
+
+	// Deleted users can't refresh
+	if err == nil {
+		var user models.User
+		if config.DB.First(&user, token.UserID).Error != nil {
+			err = errRefreshInvalid
+		}
+	}
+
+	var pair tokenPair
+	if err == nil {
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			// Of two requests racing with the same token only one wins
+			used := tx.Model(&token).Where("used_at IS NULL").Update("used_at", now)
+			if used.Error != nil {
+				return used.Error
+			}
+			if used.RowsAffected == 0 {
+				return errRefreshReused
+			}
+
+			var err error
+			pair, err = issueTokens(tx, token.UserID, token.FamilyID, now)
+			return err
+		})
+	}
+
+	if err == errRefreshReused {
+		if err := revokeRefreshTokens(config.DB, now, "family_id = ?", token.FamilyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to revoke session",
+			})
+			return
+		}
+	}
+
+	if err == errRefreshReused || err == errRefreshInvalid || errors.Is(err, gorm.ErrRecordNotFound) {
+		clearTokenCookies(c)
+		message := err.Error()
+		if err != errRefreshReused {
+			message = errRefreshInvalid.Error()
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": message,
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to refresh token",
+		})
+		return
+	}
+
+	pair.respond(c, "Token refreshed successfully")
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/tokens"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		token models.RefreshToken
+		want  error
+	}{
+		{name: "Valid", token: models.RefreshToken{ExpiresAt: now.Add(time.Hour)}, want: nil},
+		{name: "Expired", token: models.RefreshToken{ExpiresAt: now}, want: errRefreshInvalid},
+		{name: "Revoked", token: models.RefreshToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &earlier}, want: errRefreshInvalid},
+		{name: "Used", token: models.RefreshToken{ExpiresAt: now.Add(time.Hour), UsedAt: &earlier}, want: errRefreshReused},
+		{name: "Used And Revoked", token: models.RefreshToken{ExpiresAt: now.Add(time.Hour), UsedAt: &earlier, RevokedAt: &earlier}, want: errRefreshInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkRefreshToken(tt.token, now))
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	used := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name           string
+		body           string
+		cookie         bool
+		csrf           string
+		stored         *models.RefreshToken
+		noRows         string
+		expectedStatus int
+		shouldContain  []string
+	}{
+		{
+			name:           "Rotates Token",
+			body:           `{"refresh_token":"rt_abc"}`,
+			stored:         &models.RefreshToken{UserID: 7, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)},
+			expectedStatus: http.StatusOK,
+			shouldContain: []string{
+				"UPDATE refresh_tokens SET used_at=",
+				"WHERE used_at IS NULL AND id = 1",
+				"INSERT INTO refresh_tokens (user_id,family_id,token_hash,expires_at,used_at,revoked_at,created_at) VALUES (7,'fam',",
+			},
+		},
+		{
+			name:           "Token From Cookie",
+			cookie:         true,
+			csrf:           tokens.CSRF("", "fam"),
+			stored:         &models.RefreshToken{UserID: 7, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Cookie Without CSRF Token",
+			cookie:         true,
+			stored:         &models.RefreshToken{UserID: 7, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Cookie With Other Session's CSRF Token",
+			cookie:         true,
+			csrf:           tokens.CSRF("", "other"),
+			stored:         &models.RefreshToken{UserID: 7, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Reuse Revokes Family",
+			body:           `{"refresh_token":"rt_abc"}`,
+			stored:         &models.RefreshToken{UserID: 7, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour), UsedAt: &used},
+			expectedStatus: http.StatusUnauthorized,
+			shouldContain:  []string{"UPDATE refresh_tokens SET revoked_at=", "WHERE revoked_at IS NULL AND family_id = 'fam'"},
+		},
+		{
+			// Another request exchanged the token after it was loaded
+			name:           "Concurrent Reuse Revokes Family",
+			body:           `{"refresh_token":"rt_abc"}`,
+			stored:         &models.RefreshToken{UserID: 7, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)},
+			noRows:         "UPDATE refresh_tokens SET used_at=",
+			expectedStatus: http.StatusUnauthorized,
+			shouldContain:  []string{"UPDATE refresh_tokens SET revoked_at=", "WHERE revoked_at IS NULL AND family_id = 'fam'"},
+		},
+		{
+			// A dry run loads a token that expired long ago
+			name:           "Expired",
+			body:           `{"refresh_token":"rt_abc"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Missing",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			if tt.stored != nil {
+				stored := *tt.stored
+				config.DB.Callback().Query().After("test:primary_key").Register("test:refresh_token", func(tx *gorm.DB) {
+					if token, ok := tx.Statement.Dest.(*models.RefreshToken); ok {
+						stored.ID = token.ID
+						*token = stored
+					}
+				})
+			}
+
+			if tt.noRows != "" {
+				matchNoRows(tt.noRows)
+			}
+
+			router := setupTestRouter()
+			router.POST("/user/refresh", RefreshToken)
+
+			req, err := http.NewRequest("POST", "/user/refresh", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.cookie {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "rt_abc"})
+			}
+			if tt.csrf != "" {
+				req.Header.Set("X-CSRF-Token", tt.csrf)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, recorder.Body.String(), `"refresh_token":"rt_`)
+				assert.Contains(t, recorder.Body.String(), `"csrf_token":"`+tokens.CSRF("", "fam")+`"`)
+
+				cookies := strings.Join(recorder.Header().Values("Set-Cookie"), "\n")
+				assert.Contains(t, cookies, "refresh_token=rt_")
+				assert.Contains(t, cookies, "csrf_token="+tokens.CSRF("", "fam"))
+			}
+
+			all := strings.Join(*statements, "\n")
+			for _, expected := range tt.shouldContain {
+				assert.Contains(t, all, expected)
+			}
+		})
+	}
+}
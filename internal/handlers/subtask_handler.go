@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxTaskDepth = 50
+
+// checkParent makes sure the user may add subtasks to the parent and that
+// placing the task under it doesn't make the task its own ancestor.
+func checkParent(userID uint, taskID uint, parentID uint) error {
+	var parent models.Task
+	err := config.DB.Scopes(visibleTasks(userID)).First(&parent, "tasks.id = ?", parentID).Error
+	if err != nil {
+		return fmt.Errorf("Parent task not found")
+	}
+
+	if !canEditTask(parent, userID) {
+		return fmt.Errorf("You don't have permission to add subtasks to the parent task")
+	}
+
+	for depth := 0; ; depth++ {
+		if parent.ID == taskID {
+			return fmt.Errorf("A task can't be nested under itself or its subtasks")
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		if depth == maxTaskDepth {
+			return fmt.Errorf("Tasks can't be nested more than %d levels deep", maxTaskDepth)
+		}
+
+		next := *parent.ParentID
+		parent = models.Task{}
+		if err := config.DB.First(&parent, next).Error; err != nil {
+			return nil
+		}
+	}
+}
+
+// completeParent completes the parent of a task once none of its subtasks are
+// open anymore, if the parent asked for it and the workflow allows the move.
+func completeParent(tx *gorm.DB, task *models.Task, userID uint) error {
+	if task.ParentID == nil {
+		return nil
+	}
+
+	var parent models.Task
+	err := tx.First(&parent, *task.ParentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !parent.CompleteWithSubtasks || !config.Workflow.Allowed(parent.Status, models.StatusCompleted) {
+		return nil
+	}
+
+	var open, completed int64
+	err = tx.Model(&models.Task{}).Where("parent_id = ? AND status NOT IN ?", parent.ID, models.ClosedTaskStatuses).Count(&open).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&models.Task{}).Where("parent_id = ? AND status = ?", parent.ID, models.StatusCompleted).Count(&completed).Error
+	if err != nil {
+		return err
+	}
+
+	// A parent whose subtasks were all cancelled wasn't completed
+	if open > 0 || completed == 0 {
+		return nil
+	}
+
+	// A parent that still waits on other tasks stays open
+	err = changeStatus(tx, &parent, models.StatusCompleted, userID)
+	if _, blocked := err.(*blockedError); blocked {
+		return nil
+	}
+	return err
+}
+
+func GetSubtasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	var tasks []models.Task
+	err := config.DB.Scopes(visibleTasks(userID), withComputedFields(time.Now())).
+		Where("tasks.parent_id = ?", task.ID).
+		Order("tasks.created_at").
+		Find(&tasks).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find subtasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+func GetChecklist(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	var items []models.ChecklistItem
+	err := config.DB.Where("task_id = ?", task.ID).Order("position, id").Find(&items).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find checklist",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func CreateChecklistItem(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Text     string `json:"text" binding:"required"`
+		Position *int   `json:"position"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	item := models.ChecklistItem{TaskID: task.ID, Text: body.Text}
+
+	// New items go to the end unless a position is given
+	if body.Position != nil {
+		item.Position = *body.Position
+	} else {
+		var last int
+		config.DB.Model(&models.ChecklistItem{}).Where("task_id = ?", task.ID).Select("COALESCE(MAX(position), -1)").Scan(&last)
+		item.Position = last + 1
+	}
+
+	// The task's checklist counts change with it
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		return touchTask(tx, &task)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error creating checklist item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item created successfully",
+		"item":    item,
+	})
+}
+
+func findChecklistItem(c *gin.Context, task models.Task) (models.ChecklistItem, bool) {
+	var item models.ChecklistItem
+	err := config.DB.First(&item, "id = ? AND task_id = ?", c.Param("item_id"), task.ID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Checklist item not found",
+		})
+		return item, false
+	}
+
+	return item, true
+}
+
+func UpdateChecklistItem(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	item, ok := findChecklistItem(c, task)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Text     string `json:"text"`
+		Done     *bool  `json:"done"`
+		Position *int   `json:"position"`
+	}
+
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fields are empty",
+		})
+		return
+	}
+
+	if body.Text != "" {
+		item.Text = body.Text
+	}
+
+	if body.Done != nil {
+		item.Done = *body.Done
+	}
+
+	if body.Position != nil {
+		item.Position = *body.Position
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+		return touchTask(tx, &task)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update checklist item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item updated successfully",
+		"item":    item,
+	})
+}
+
+func DeleteChecklistItem(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	item, ok := findChecklistItem(c, task)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		return touchTask(tx, &task)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error deleting checklist item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item deleted successfully",
+	})
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// loadTaskTree makes tasks load with the parents in the map, by ID.
+func loadTaskTree(parents map[uint]uint) {
+	taskID := regexp.MustCompile(`tasks\.id = '?(\d+)`)
+
+	onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+		task, ok := tx.Statement.Dest.(*models.Task)
+		match := taskID.FindStringSubmatch(explain(tx))
+		if !ok || match == nil {
+			return
+		}
+
+		id, _ := strconv.Atoi(match[1])
+		task.ID = uint(id)
+		if parent, ok := parents[task.ID]; ok {
+			task.ParentID = &parent
+		}
+	})
+}
+
+func TestCheckParent(t *testing.T) {
+	// A chain of tasks one level deeper than allowed, each under the next
+	deep := map[uint]uint{}
+	for id := uint(100); id < 100+maxTaskDepth+1; id++ {
+		deep[id] = id + 1
+	}
+
+	tests := []struct {
+		name     string
+		taskID   uint
+		parentID uint
+		parents  map[uint]uint
+		missing  bool
+		want     string
+	}{
+		{name: "Top Level Parent", taskID: 1, parentID: 2},
+		{name: "Nested Parent", taskID: 1, parentID: 2, parents: map[uint]uint{2: 3, 3: 4}},
+		{name: "Itself", taskID: 2, parentID: 2, want: "A task can't be nested under itself or its subtasks"},
+		{name: "Own Subtask", taskID: 1, parentID: 3, parents: map[uint]uint{3: 2, 2: 1}, want: "A task can't be nested under itself or its subtasks"},
+		{name: "Too Deep", taskID: 1, parentID: 100, parents: deep, want: fmt.Sprintf("Tasks can't be nested more than %d levels deep", maxTaskDepth)},
+		{name: "Parent Not Found", taskID: 1, parentID: 2, missing: true, want: "Parent task not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			loadTaskTree(tt.parents)
+			if tt.missing {
+				onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+					tx.AddError(gorm.ErrRecordNotFound)
+				})
+			}
+
+			// A dry run loads tasks created by user 0, so the caller can edit them
+			err = checkParent(0, tt.taskID, tt.parentID)
+			if tt.want == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteParent(t *testing.T) {
+	tests := []struct {
+		name                 string
+		completeWithSubtasks bool
+		open                 int64
+		completed            int64
+		blockers             []uint
+		wantCompleted        bool
+	}{
+		{name: "Last Subtask Closed", completeWithSubtasks: true, completed: 2, wantCompleted: true},
+		{name: "Parent Didn't Ask", completed: 2},
+		{name: "Subtasks Still Open", completeWithSubtasks: true, open: 1, completed: 1},
+		{name: "All Subtasks Cancelled", completeWithSubtasks: true},
+		{name: "Parent Still Blocked", completeWithSubtasks: true, completed: 2, blockers: []uint{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			onQuery("tasks.id = 9", func(tx *gorm.DB) {
+				if parent, ok := tx.Statement.Dest.(*models.Task); ok {
+					parent.ID = 9
+					parent.Status = models.StatusOngoing
+					parent.CompleteWithSubtasks = tt.completeWithSubtasks
+				}
+			})
+			count := func(value int64) func(tx *gorm.DB) {
+				return func(tx *gorm.DB) {
+					if count, ok := tx.Statement.Dest.(*int64); ok {
+						*count = value
+						tx.RowsAffected = 1
+					}
+				}
+			}
+			onQuery("parent_id = 9 AND status NOT IN", count(tt.open))
+			onQuery("parent_id = 9 AND status = 'completed'", count(tt.completed))
+			onQuery("FROM task_dependencies", func(tx *gorm.DB) {
+				if blockers, ok := tx.Statement.Dest.(*[]uint); ok {
+					*blockers = tt.blockers
+				}
+			})
+
+			parentID := uint(9)
+			subtask := models.Task{Status: models.StatusCompleted, ParentID: &parentID}
+			subtask.ID = 5
+
+			assert.NoError(t, completeParent(config.DB, &subtask, 7))
+
+			all := strings.Join(*statements, "\n")
+			if tt.wantCompleted {
+				assert.Contains(t, all, "UPDATE tasks SET status='completed'")
+				assert.Contains(t, all, "WHERE (id = 9 AND version = 0)")
+			} else {
+				assert.NotContains(t, all, "UPDATE tasks SET status=")
+			}
+		})
+	}
+}
+
+func TestProgressCounts(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	onQuery("AS checklist_done", func(tx *gorm.DB) {
+		if task, ok := tx.Statement.Dest.(*models.Task); ok {
+			task.SubtasksTotal, task.SubtasksDone = 3, 1
+			task.ChecklistTotal, task.ChecklistDone = 4, 2
+		}
+	})
+
+	// A dry run loads tasks created by user 0, so the caller owns them
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(0))
+	})
+	router.GET("/v1/tasks/:id", GetTask)
+
+	req, err := createJSONRequest("GET", "/v1/tasks/42", nil)
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	for _, expected := range []string{`"subtasks_total":3`, `"subtasks_done":1`, `"checklist_total":4`, `"checklist_done":2`} {
+		assert.Contains(t, recorder.Body.String(), expected)
+	}
+
+	// Cancelled subtasks and deleted checklist items don't count
+	all := strings.Join(*statements, "\n")
+	assert.Contains(t, all, "subtasks.deleted_at IS NULL AND subtasks.status <> 'cancelled') AS subtasks_total")
+	assert.Contains(t, all, "subtasks.deleted_at IS NULL AND subtasks.status = 'completed') AS subtasks_done")
+	assert.Contains(t, all, "checklist_items.deleted_at IS NULL) AS checklist_total")
+	assert.Contains(t, all, "checklist_items.deleted_at IS NULL AND checklist_items.done) AS checklist_done")
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errShareNotFound = errors.New("Share not found")
+
+type taskAccess int
+
+const (
+	accessRead taskAccess = iota
+	accessWrite
+	accessOwner
+)
+
+// visibleTasks limits a task query to tasks the user created, that were
+// shared with them or assigned to them, or that belong to a project of one
+// of their teams.
+func visibleTasks(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		shared := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.TaskShare{}).
+			Select("task_id").
+			Where("user_id = ?", userID)
+
+		assigned := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.TaskAssignee{}).
+			Select("task_id").
+			Where("user_id = ?", userID)
+
+		teamProjects := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.Project{}).
+			Select("id").
+			Where("team_id IN (?)", memberTeams(db, userID, models.RoleViewer))
+
+		return db.Where("tasks.created_by = ? OR tasks.id IN (?) OR tasks.id IN (?) OR tasks.project_id IN (?)", userID, shared, assigned, teamProjects)
+	}
+}
+
+func canEditTask(task models.Task, userID uint) bool {
+	if task.CreatedBy == userID {
+		return true
+	}
+
+	var share models.TaskShare
+	err := config.DB.Where("task_id = ? AND user_id = ? AND can_edit = ?", task.ID, userID, true).First(&share).Error
+	if err == nil {
+		return true
+	}
+
+	// Being assigned doesn't give write access, so a read-only share stays
+	// read-only
+	return taskTeamRole(task, userID).AtLeast(models.RoleMember)
+}
+
+// canManageTask allows deleting and sharing a task to its creator and to
+// admins of the team that owns its project.
+func canManageTask(task models.Task, userID uint) bool {
+	return task.CreatedBy == userID || taskTeamRole(task, userID).AtLeast(models.RoleAdmin)
+}
+
+// taskIDParam reads the task ID from the path of /v1 routes, or from the
+// task_id query parameter of the deprecated /task routes.
+func taskIDParam(c *gin.Context) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	return c.Query("task_id")
+}
+
+// findTask loads a task for the current user with the requested access.
+// Tasks the user can't see are reported as missing, so their existence is
+// never leaked; visible tasks the user may not change get a 403.
+func findTask(c *gin.Context, taskID string, access taskAccess) (models.Task, bool) {
+	userID := c.GetUint("user_id")
+
+	var task models.Task
+	err := config.DB.Scopes(visibleTasks(userID), withComputedFields(time.Now())).First(&task, "tasks.id = ?", taskID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Task not found",
+		})
+		return task, false
+	}
+
+	return task, checkTaskAccess(c, task, access)
+}
+
+// findTrashedTask is findTask for deleted tasks.
+func findTrashedTask(c *gin.Context, taskID string, access taskAccess) (models.Task, bool) {
+	userID := c.GetUint("user_id")
+
+	var task models.Task
+	err := config.DB.Unscoped().Scopes(visibleTasks(userID)).
+		First(&task, "tasks.id = ? AND tasks.deleted_at IS NOT NULL", taskID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Task not found in trash",
+		})
+		return task, false
+	}
+
+	return task, checkTaskAccess(c, task, access)
+}
+
+func checkTaskAccess(c *gin.Context, task models.Task, access taskAccess) bool {
+	userID := c.GetUint("user_id")
+
+	allowed := true
+	switch access {
+	case accessWrite:
+		allowed = canEditTask(task, userID)
+	case accessOwner:
+		allowed = canManageTask(task, userID)
+	}
+
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You don't have permission to modify this task",
+		})
+	}
+
+	return allowed
+}
+
+func ShareTask(c *gin.Context) {
+	task, ok := findTask(c, taskIDParam(c), accessOwner)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		UserID  uint `json:"user_id" binding:"required"`
+		CanEdit bool `json:"can_edit"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	if body.UserID == task.CreatedBy {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Task can't be shared with its owner",
+		})
+		return
+	}
+
+	// Find user to share with
+	var user models.User
+	err := config.DB.First(&user, body.UserID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	share := models.TaskShare{TaskID: task.ID, UserID: user.ID}
+	err = config.DB.Where(share).Assign(map[string]interface{}{"can_edit": body.CanEdit}).FirstOrCreate(&share).Error
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error sharing task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task shared successfully",
+		"share":   share,
+	})
+}
+
+func UnshareTask(c *gin.Context) {
+	task, ok := findTask(c, taskIDParam(c), accessOwner)
+	if !ok {
+		return
+	}
+
+	shareUserID := c.Param("user_id")
+	if shareUserID == "" {
+		shareUserID = c.Query("user_id")
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("task_id = ? AND user_id = ?", task.ID, shareUserID).Delete(&models.TaskShare{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errShareNotFound
+		}
+
+		// Without the share the user may no longer belong to the task
+		return pruneAssignees(tx, &task)
+	})
+
+	if err == errShareNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error unsharing task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task unshared successfully",
+	})
+}
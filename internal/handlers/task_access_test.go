@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTaskQueriesAreScopedToUser(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	})
+	router.GET("/task/", GetTasks)
+	router.PUT("/task/update", UpdateTasks)
+	router.DELETE("/task/delete", DeleteTask)
+	router.POST("/task/share", ShareTask)
+	router.GET("/task/:id/subtasks", GetSubtasks)
+	router.GET("/task/:id/checklist", GetChecklist)
+	router.POST("/task/:id/checklist", CreateChecklistItem)
+	router.GET("/task/:id/graph", GetDependencyGraph)
+	router.GET("/project/:id/tasks", GetProjectTasks)
+	router.GET("/project/:id/stats", GetProjectStats)
+	router.GET("/task/assigned-to-me", GetAssignedTasks)
+	router.POST("/task/:id/assignees", AssignTask)
+	router.DELETE("/task/:id/assignees/:user_id", UnassignTask)
+	router.GET("/task/:id/comments", GetComments)
+	router.PUT("/task/:id/comments/:comment_id", UpdateComment)
+	router.GET("/user/mentions", GetMentions)
+	router.GET("/task/:id/attachments", GetAttachments)
+	router.GET("/task/:id/history", GetTaskHistory)
+	router.GET("/user/activity", GetActivity)
+	router.GET("/task/trash", GetTrash)
+	router.POST("/task/:id/restore", RestoreTask)
+	router.DELETE("/task/:id/purge", PurgeTask)
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   map[string]interface{}
+	}{
+		{name: "List", method: "GET", url: "/task/"},
+		{name: "Get by ID", method: "GET", url: "/task/?task_id=42"},
+		{name: "Update", method: "PUT", url: "/task/update?task_id=42", body: map[string]interface{}{"title": "x"}},
+		{name: "Delete", method: "DELETE", url: "/task/delete?task_id=42"},
+		{name: "Share", method: "POST", url: "/task/share?task_id=42", body: map[string]interface{}{"user_id": 8}},
+		{name: "Subtasks", method: "GET", url: "/task/42/subtasks"},
+		{name: "Checklist", method: "GET", url: "/task/42/checklist"},
+		{name: "Add Checklist Item", method: "POST", url: "/task/42/checklist", body: map[string]interface{}{"text": "x"}},
+		{name: "Dependency Graph", method: "GET", url: "/task/42/graph"},
+		{name: "Project Tasks", method: "GET", url: "/project/3/tasks"},
+		{name: "Project Stats", method: "GET", url: "/project/3/stats"},
+		{name: "Assigned To Me", method: "GET", url: "/task/assigned-to-me"},
+		{name: "Assign", method: "POST", url: "/task/42/assignees", body: map[string]interface{}{"user_id": 8}},
+		{name: "Unassign", method: "DELETE", url: "/task/42/assignees/8"},
+		{name: "Comments", method: "GET", url: "/task/42/comments"},
+		{name: "Edit Comment", method: "PUT", url: "/task/42/comments/5", body: map[string]interface{}{"body": "x"}},
+		{name: "Mentions", method: "GET", url: "/user/mentions"},
+		{name: "Attachments", method: "GET", url: "/task/42/attachments"},
+		{name: "History", method: "GET", url: "/task/42/history"},
+		{name: "Activity", method: "GET", url: "/user/activity?user_id=8"},
+		{name: "Trash", method: "GET", url: "/task/trash"},
+		{name: "Restore", method: "POST", url: "/task/42/restore"},
+		{name: "Purge", method: "DELETE", url: "/task/42/purge"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*statements = (*statements)[:0]
+
+			req, err := createJSONRequest(tt.method, tt.url, tt.body)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			// Every lookup of tasks must be limited to the caller
+			lookups := 0
+			for _, statement := range *statements {
+				if strings.Contains(statement, " FROM tasks WHERE ") {
+					lookups++
+					assert.Contains(t, statement, "tasks.created_by = 7 OR tasks.id IN (SELECT task_id FROM task_shares WHERE user_id = 7")
+				}
+			}
+			assert.NotZero(t, lookups)
+		})
+	}
+}
+
+func TestFindTaskRejectsForeignTasks(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	})
+	router.DELETE("/task/delete", DeleteTask)
+
+	// A dry run loads an empty task owned by user 0, which is not the caller
+	req, err := createJSONRequest("DELETE", "/task/delete?task_id=42", nil)
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestAssigneesNeedEditAccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		canEdit        bool
+		expectedStatus int
+	}{
+		{name: "Read-Only Share", canEdit: false, expectedStatus: http.StatusForbidden},
+		{name: "Share With Edit", canEdit: true, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			// User 7 is assigned to a task of user 1 that is shared with them
+			onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+				if task, ok := tx.Statement.Dest.(*models.Task); ok {
+					task.CreatedBy = 1
+				}
+			})
+			onQuery("FROM task_assignees WHERE task_id", func(tx *gorm.DB) {
+				if count, ok := tx.Statement.Dest.(*int64); ok {
+					*count = 1
+					tx.RowsAffected = 1
+				}
+			})
+			onQuery("can_edit = true", func(tx *gorm.DB) {
+				if !tt.canEdit {
+					tx.AddError(gorm.ErrRecordNotFound)
+				}
+			})
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+			})
+			router.PATCH("/v1/tasks/:id", PatchTask)
+
+			req, err := createJSONRequest("PATCH", "/v1/tasks/42", map[string]interface{}{"title": "Renamed"})
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.NotContains(t, strings.Join(*statements, "\n"), "UPDATE tasks")
+			}
+		})
+	}
+}
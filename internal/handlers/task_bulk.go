@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/patch"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+const maxBulkOperations = 500
+
+// bulkOperation is one item of a bulk request. Which fields are used depends
+// on the op:
+//
+//	create     task holds the fields of POST /v1/tasks
+//	update     id, and task holds a merge patch as for PATCH /v1/tasks/:id
+//	status     id and status
+//	delete     id
+//	add_label  id and label_id
+//
+// version works like If-Match for everything but create.
+type bulkOperation struct {
+	Op      string            `json:"op"`
+	ID      uint              `json:"id"`
+	Version *uint             `json:"version"`
+	Task    json.RawMessage   `json:"task"`
+	Status  models.TaskStatus `json:"status"`
+	LabelID uint              `json:"label_id"`
+}
+
+type bulkResult struct {
+	Index  int          `json:"index"`
+	Status int          `json:"status"`
+	Task   *models.Task `json:"task,omitempty"`
+	Error  gin.H        `json:"error,omitempty"`
+}
+
+// bulkError is an operation that failed with the given status code.
+type bulkError struct {
+	status  int
+	message string
+}
+
+func (e *bulkError) Error() string {
+	return e.message
+}
+
+// findBulkTask is findTask for bulk operations, which read through the
+// transaction so they see the operations before them.
+func findBulkTask(tx *gorm.DB, userID uint, taskID uint, access taskAccess) (models.Task, error) {
+	var task models.Task
+	err := tx.Scopes(visibleTasks(userID)).First(&task, "tasks.id = ?", taskID).Error
+	if err != nil {
+		return task, &bulkError{http.StatusNotFound, "Task not found"}
+	}
+
+	allowed := true
+	switch access {
+	case accessWrite:
+		allowed = canEditTask(task, userID)
+	case accessOwner:
+		allowed = canManageTask(task, userID)
+	}
+	if !allowed {
+		return task, &bulkError{http.StatusForbidden, "You don't have permission to modify this task"}
+	}
+
+	return task, nil
+}
+
+// run applies the operation and returns the task it left behind, if any.
+func (op bulkOperation) run(tx *gorm.DB, userID uint) (*models.Task, error) {
+	if op.Op == "create" {
+		var in createTaskInput
+		if err := json.Unmarshal(op.Task, &in); err != nil || binding.Validator.ValidateStruct(&in) != nil {
+			return nil, &bulkError{http.StatusBadRequest, "Fields are empty or invalid"}
+		}
+
+		created, err := in.build(userID)
+		if err != nil {
+			return nil, &bulkError{http.StatusBadRequest, err.Error()}
+		}
+		if err := createTask(tx, &created, userID); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+
+	access := accessWrite
+	if op.Op == "delete" {
+		access = accessOwner
+	}
+
+	found, err := findBulkTask(tx, userID, op.ID, access)
+	if err != nil {
+		return nil, err
+	}
+	if op.Version != nil && *op.Version != found.Version {
+		return &found, errVersionConflict
+	}
+	before := found
+
+	switch op.Op {
+	case "update":
+		status, err := patchTask(&found, patch.Merge, op.Task, userID)
+		if err == nil {
+			err = savePatchedTask(tx, &found, before, status, userID)
+		}
+
+		// Assignees have to belong to the task's new project
+		if err == nil && !sameID(found.ProjectID, before.ProjectID) {
+			err = pruneAssignees(tx, &found)
+		}
+		return &found, err
+	case "status":
+		if !op.Status.Valid() {
+			return &found, &bulkError{http.StatusBadRequest, "Status is empty or invalid"}
+		}
+		return &found, changeStatus(tx, &found, op.Status, userID)
+	case "delete":
+		result := tx.Where("version = ?", found.Version).Delete(&found)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, errVersionConflict
+		}
+		if err := touchParents(tx, found); err != nil {
+			return nil, err
+		}
+		return nil, recordHistory(tx, found, models.HistoryDeleted, userID)
+	case "add_label":
+		var label models.Label
+		if err := tx.Scopes(visibleLabels(userID)).First(&label, "labels.id = ?", op.LabelID).Error; err != nil {
+			return &found, &bulkError{http.StatusNotFound, "Label not found"}
+		}
+		if err := tx.Model(&found).Association("Labels").Append(&label); err != nil {
+			return &found, err
+		}
+		return &found, touchTask(tx, &found)
+	default:
+		return nil, &bulkError{http.StatusBadRequest, fmt.Sprintf("Unknown op %q", op.Op)}
+	}
+}
+
+func (op bulkOperation) result(index int, task *models.Task, err error) bulkResult {
+	result := bulkResult{Index: index, Status: http.StatusOK, Task: task}
+
+	var failed *bulkError
+	switch {
+	case err == nil && op.Op == "create":
+		result.Status = http.StatusCreated
+	case err == nil && op.Op == "delete":
+		result.Status = http.StatusNoContent
+	case errors.As(err, &failed):
+		result.Status, result.Task, result.Error = failed.status, nil, gin.H{"error": failed.message}
+	case err != nil:
+		var current models.Task
+		if task != nil {
+			current = *task
+		}
+		result.Status, result.Error = taskErrorResponse(err, current)
+		result.Task = nil
+	}
+
+	return result
+}
+
+// BulkTasks applies a list of operations in one transaction. By default each
+// operation succeeds or fails on its own; with "atomic" set the first failure
+// rolls back all of them.
+func BulkTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		Atomic     bool            `json:"atomic"`
+		Operations []bulkOperation `json:"operations" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Operations are empty or invalid"})
+		return
+	}
+
+	if len(body.Operations) > maxBulkOperations {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("A bulk request can hold up to %d operations", maxBulkOperations),
+		})
+		return
+	}
+
+	results := make([]bulkResult, 0, len(body.Operations))
+	failed := false
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for i, op := range body.Operations {
+			// Each operation gets a savepoint, so a failed one can be undone
+			// without losing the others
+			savepoint := fmt.Sprintf("bulk_%d", i)
+			if !body.Atomic {
+				if err := tx.SavePoint(savepoint).Error; err != nil {
+					return err
+				}
+			}
+
+			task, err := op.run(tx, userID)
+			results = append(results, op.result(i, task, err))
+
+			if err != nil {
+				if body.Atomic {
+					failed = true
+					return err
+				}
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if failed {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   fmt.Sprintf("Operation %d failed, no changes were made", len(results)-1),
+			"results": results,
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to apply operations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+	})
+}
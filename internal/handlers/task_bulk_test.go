@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBulkTasks(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	// A dry run loads tasks at version 0 created by user 0, so the caller owns them
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(0))
+	})
+	router.POST("/v1/tasks/bulk", BulkTasks)
+
+	operations := []map[string]interface{}{
+		{"op": "create", "task": map[string]interface{}{"title": "Test Task", "description": "This is a test task"}},
+		{"op": "create", "task": map[string]interface{}{"title": "No description"}},
+		{"op": "update", "id": 42, "task": map[string]interface{}{"title": "Renamed", "due_at": nil}},
+		{"op": "update", "id": 42, "task": map[string]interface{}{"priority": "whenever"}},
+		{"op": "status", "id": 42, "status": "sleeping"},
+		{"op": "add_label", "id": 42, "label_id": 3},
+		{"op": "delete", "id": 42, "version": 5},
+		{"op": "delete", "id": 42, "version": 0},
+		{"op": "archive", "id": 42},
+	}
+
+	tests := []struct {
+		name             string
+		requestBody      map[string]interface{}
+		raced            bool
+		expectedStatus   int
+		expectedStatuses []int
+	}{
+		{
+			name:             "Independent Operations",
+			requestBody:      map[string]interface{}{"operations": operations},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []int{201, 400, 200, 422, 400, 200, 412, 204, 400},
+		},
+		{
+			name:             "Atomic Stops At First Failure",
+			requestBody:      map[string]interface{}{"atomic": true, "operations": operations},
+			expectedStatus:   http.StatusUnprocessableEntity,
+			expectedStatuses: []int{201, 400},
+		},
+		{
+			name:             "Atomic Success",
+			requestBody:      map[string]interface{}{"atomic": true, "operations": operations[:1]},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []int{201},
+		},
+		{
+			// The task was saved by someone else after it was loaded
+			name:             "Delete Raced",
+			requestBody:      map[string]interface{}{"operations": operations[7:8]},
+			raced:            true,
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []int{412},
+		},
+		{
+			name:           "No Operations",
+			requestBody:    map[string]interface{}{"operations": []interface{}{}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Too Many Operations",
+			requestBody:    map[string]interface{}{"operations": make([]map[string]interface{}, maxBulkOperations+1)},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.raced {
+				matchNoRows("version = 0")
+			}
+
+			req, err := createJSONRequest("POST", "/v1/tasks/bulk", tt.requestBody)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			var response struct {
+				Results []bulkResult `json:"results"`
+			}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+			var statuses []int
+			for i, result := range response.Results {
+				assert.Equal(t, i, result.Index)
+				statuses = append(statuses, result.Status)
+			}
+			assert.Equal(t, tt.expectedStatuses, statuses)
+		})
+	}
+}
+
+func TestBulkPrunesAssigneesWithEachOperation(t *testing.T) {
+	operations := []map[string]interface{}{
+		{"op": "update", "id": 42, "task": map[string]interface{}{"project_id": nil}},
+		{"op": "create", "task": map[string]interface{}{"title": "Test Task", "description": "This is a test task"}},
+	}
+
+	tests := []struct {
+		name             string
+		pruneFails       bool
+		expectedStatuses []int
+	}{
+		{name: "Pruned", expectedStatuses: []int{200, 201}},
+		{name: "Pruning Fails", pruneFails: true, expectedStatuses: []int{400, 201}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			// The task is in project 3 and assigned to user 8, who can't see
+			// it without the project
+			onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+				if task, ok := tx.Statement.Dest.(*models.Task); ok {
+					projectID := uint(3)
+					task.ProjectID = &projectID
+				}
+			})
+			onQuery("FROM task_assignees WHERE task_id", func(tx *gorm.DB) {
+				if assignees, ok := tx.Statement.Dest.(*[]models.TaskAssignee); ok {
+					*assignees = []models.TaskAssignee{{ID: 5, TaskID: 42, UserID: 8}}
+				}
+			})
+			if tt.pruneFails {
+				config.DB.Callback().Delete().After("test:capture_delete").Register("test:prune_fails", func(tx *gorm.DB) {
+					if strings.Contains(explain(tx), "DELETE FROM task_assignees") {
+						tx.AddError(errors.New("connection reset"))
+					}
+				})
+			}
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(0))
+			})
+			router.POST("/v1/tasks/bulk", BulkTasks)
+
+			req, err := createJSONRequest("POST", "/v1/tasks/bulk", map[string]interface{}{"operations": operations})
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			// A failed prune only fails its own operation
+			assert.Equal(t, http.StatusOK, recorder.Code)
+
+			var response struct {
+				Results []bulkResult `json:"results"`
+			}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+			var statuses []int
+			for _, result := range response.Results {
+				statuses = append(statuses, result.Status)
+			}
+			assert.Equal(t, tt.expectedStatuses, statuses)
+
+			// Pruning happens with the update, before the next operation
+			all := strings.Join(*statements, "\n")
+			pruned := strings.Index(all, "DELETE FROM task_assignees WHERE task_assignees.id = 5")
+			created := strings.Index(all, "INSERT INTO tasks")
+			assert.NotEqual(t, -1, pruned)
+			assert.Less(t, pruned, created)
+		})
+	}
+}
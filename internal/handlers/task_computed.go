@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"task-manager/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// withComputedFields loads every task column plus the values derived from
+// other rows: the urgency score and the subtask and checklist progress.
+// Cancelled subtasks don't count towards progress.
+func withComputedFields(asOf time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Select(`tasks.*, ? AS urgency,
+			(SELECT COUNT(*) FROM tasks AS subtasks
+				WHERE subtasks.parent_id = tasks.id AND subtasks.deleted_at IS NULL AND subtasks.status <> ?) AS subtasks_total,
+			(SELECT COUNT(*) FROM tasks AS subtasks
+				WHERE subtasks.parent_id = tasks.id AND subtasks.deleted_at IS NULL AND subtasks.status = ?) AS subtasks_done,
+			(SELECT COUNT(*) FROM checklist_items
+				WHERE checklist_items.task_id = tasks.id AND checklist_items.deleted_at IS NULL) AS checklist_total,
+			(SELECT COUNT(*) FROM checklist_items
+				WHERE checklist_items.task_id = tasks.id AND checklist_items.deleted_at IS NULL AND checklist_items.done) AS checklist_done`,
+			urgencyExpr(asOf), models.StatusCancelled, models.StatusCompleted)
+	}
+}
@@ -1,14 +1,102 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"task-manager/config"
 	"task-manager/internal/models"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// createTask inserts the task and records when it entered its first status.
+func createTask(tx *gorm.DB, task *models.Task, userID uint) error {
+	task.Version = 1
+
+	if err := tx.Create(task).Error; err != nil {
+		return err
+	}
+
+	if err := recordHistory(tx, *task, models.HistoryCreated, userID); err != nil {
+		return err
+	}
+
+	if err := touchParents(tx, *task); err != nil {
+		return err
+	}
+
+	return tx.Create(&models.TaskStatusChange{
+		TaskID:    task.ID,
+		ToStatus:  task.Status,
+		ChangedBy: userID,
+		EnteredAt: task.StatusChangedAt,
+	}).Error
+}
+
+type createTaskInput struct {
+	Title                string              `json:"title" binding:"required"`
+	Description          string              `json:"description" binding:"required"`
+	Priority             models.TaskPriority `json:"priority"`
+	ParentID             *uint               `json:"parent_id"`
+	CompleteWithSubtasks bool                `json:"complete_with_subtasks"`
+	Recurrence           string              `json:"recurrence"`
+	ProjectID            *uint               `json:"project_id"`
+	taskScheduleInput
+}
+
+// build validates the input and returns the new task.
+func (in createTaskInput) build(userID uint) (models.Task, error) {
+	if in.Priority == "" {
+		in.Priority = models.PriorityNormal
+	}
+
+	if !in.Priority.Valid() {
+		return models.Task{}, fmt.Errorf("Invalid priority")
+	}
+
+	recurrence, err := parseRecurrence(in.Recurrence)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	if in.ParentID != nil {
+		if err := checkParent(userID, 0, *in.ParentID); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	if in.ProjectID != nil {
+		if err := checkProject(userID, *in.ProjectID); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	date := time.Now()
+
+	task := models.Task{
+		Title:           in.Title,
+		Description:     in.Description,
+		CreatedBy:       userID,
+		Date:            date,
+		Status:          models.StatusNew,
+		StatusChangedAt: date,
+		Priority:        in.Priority,
+
+		ParentID:             in.ParentID,
+		CompleteWithSubtasks: in.CompleteWithSubtasks,
+		Recurrence:           recurrence,
+		ProjectID:            in.ProjectID,
+	}
+
+	if err := in.apply(&task); err != nil {
+		return models.Task{}, err
+	}
+
+	return task, nil
+}
+
 func CreateTask(c *gin.Context) {
 	userID := c.GetUint("user_id")
 
@@ -23,44 +111,55 @@ func CreateTask(c *gin.Context) {
 	}
 
 	// Obtain data from request
-	var body struct {
-		Title       string `json:"title" binding:"required"`
-		Description string `json:"description" binding:"required"`
-	}
+	var body createTaskInput
 
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
 		return
 	}
 
-	date := time.Now()
-
-	newTask := models.Task{
-		Title:       body.Title,
-		Description: body.Description,
-		CreatedBy:   userID,
-		Date:        date,
+	newTask, err := body.build(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	result := config.DB.Create(&newTask)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		return createTask(tx, &newTask, userID)
+	})
 
-	if result.Error != nil {
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Error creating task",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Task created successfully",
-	})
+	if c.GetBool("deprecated_route") {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Task created successfully",
+		})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/v1/tasks/%d", newTask.ID))
+	c.Header("ETag", taskETag(newTask))
+	c.JSON(http.StatusCreated, newTask)
 }
 
 func GetTasks(c *gin.Context) {
-	task_id := c.Query("task_id")
+	userID := c.GetUint("user_id")
+
+	query, err := parseTaskQuery(c.Request.URL.Query(), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 
 	var tasks []models.Task
-	err := config.DB.Find(&tasks, task_id).Error
+	err = config.DB.Scopes(visibleTasks(userID), query.scope).Preload("Labels").Preload("Assignees").Find(&tasks).Error
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -69,25 +168,55 @@ func GetTasks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	tasks, nextCursor := query.page(tasks)
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":       tasks,
+		"next_cursor": nextCursor,
+	})
 }
 
-func UpdateTasks(c *gin.Context) {
-	task_id := c.Query("task_id")
+func GetTask(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok || notModified(c, task) {
+		return
+	}
 
-	var task models.Task
-	err := config.DB.First(&task, task_id).Error
+	err := config.DB.Model(&task).Association("Labels").Find(&task.Labels)
+	if err == nil {
+		err = config.DB.Model(&task).Association("Assignees").Find(&task.Assignees)
+	}
 
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Task not found",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find task",
 		})
 		return
 	}
 
+	c.JSON(http.StatusOK, task)
+}
+
+// UpdateTasks serves the deprecated PUT /task/update, which ignores empty
+// fields. PATCH /v1/tasks/:id is handled by PatchTask.
+func UpdateTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, taskIDParam(c), accessWrite)
+	if !ok || !checkIfMatch(c, task) {
+		return
+	}
+	before := task
+
 	var body struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
+		Title                string              `json:"title"`
+		Description          string              `json:"description"`
+		Priority             models.TaskPriority `json:"priority"`
+		ParentID             *uint               `json:"parent_id"`
+		CompleteWithSubtasks *bool               `json:"complete_with_subtasks"`
+		Recurrence           *string             `json:"recurrence"`
+		ProjectID            *uint               `json:"project_id"`
+		taskScheduleInput
 	}
 
 	if c.Bind(&body) != nil {
@@ -97,6 +226,13 @@ func UpdateTasks(c *gin.Context) {
 		return
 	}
 
+	if err := body.apply(&task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	if body.Title != "" {
 		task.Title = body.Title
 	}
@@ -105,7 +241,84 @@ func UpdateTasks(c *gin.Context) {
 		task.Description = body.Description
 	}
 
-	err = config.DB.Save(&task).Error
+	if body.Priority != "" {
+		if !body.Priority.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid priority",
+			})
+			return
+		}
+		task.Priority = body.Priority
+	}
+
+	// A parent_id of 0 turns a subtask back into a top level task
+	if body.ParentID != nil {
+		task.ParentID = nil
+		if *body.ParentID != 0 {
+			if err := checkParent(userID, task.ID, *body.ParentID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			task.ParentID = body.ParentID
+		}
+	}
+
+	if body.CompleteWithSubtasks != nil {
+		task.CompleteWithSubtasks = *body.CompleteWithSubtasks
+	}
+
+	// A project_id of 0 takes the task out of its project
+	if body.ProjectID != nil && !sameID(body.ProjectID, before.ProjectID) {
+		if err := checkProjectChange(before, userID, *body.ProjectID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		task.ProjectID = nil
+		if *body.ProjectID != 0 {
+			task.ProjectID = body.ProjectID
+		}
+	}
+
+	// An empty recurrence stops the task from repeating
+	if body.Recurrence != nil {
+		recurrence, err := parseRecurrence(*body.Recurrence)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		task.Recurrence = recurrence
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveTask(tx, &task); err != nil {
+			return err
+		}
+		if !sameID(before.ParentID, task.ParentID) {
+			if err := touchParents(tx, before, task); err != nil {
+				return err
+			}
+		}
+		if err := recordChanges(tx, before, task, userID); err != nil {
+			return err
+		}
+
+		// Assignees have to belong to the task's new project
+		if !sameID(before.ProjectID, task.ProjectID) {
+			return pruneAssignees(tx, &task)
+		}
+		return nil
+	})
+
+	if err == errVersionConflict {
+		respondVersionConflict(c, before)
+		return
+	}
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -114,6 +327,7 @@ func UpdateTasks(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", taskETag(task))
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Task updated successfully",
 		"task":    task,
@@ -121,20 +335,31 @@ func UpdateTasks(c *gin.Context) {
 }
 
 func DeleteTask(c *gin.Context) {
-	task_id := c.Query("task_id")
+	task, ok := findTask(c, taskIDParam(c), accessOwner)
+	if !ok || !checkIfMatch(c, task) {
+		return
+	}
 
-	var task models.Task
-	err := config.DB.First(&task, task_id).Error
+	// Deleted tasks go to the trash, see trash_handler.go
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("version = ?", task.Version).Delete(&task)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		if err := touchParents(tx, task); err != nil {
+			return err
+		}
+		return recordHistory(tx, task, models.HistoryDeleted, c.GetUint("user_id"))
+	})
 
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Task not found",
-		})
+	if err == errVersionConflict {
+		respondVersionConflict(c, task)
 		return
 	}
 
-	err = config.DB.Delete(&models.Task{}, task_id).Error
-
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Error deleting task",
@@ -142,7 +367,12 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Task deleted successfully",
-	})
+	if c.GetBool("deprecated_route") {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Task deleted successfully",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"task-manager/internal/middlewares"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -207,3 +208,70 @@ func TestUpdateTask(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskRouteVersions(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	// A dry run loads tasks created by user 0, so the caller owns them
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(0))
+	})
+
+	tasks := router.Group("/v1/tasks")
+	tasks.POST("", CreateTask)
+	tasks.GET("/:id", GetTask)
+	tasks.PATCH("/:id", PatchTask)
+	tasks.DELETE("/:id", DeleteTask)
+
+	legacy := router.Group("/task", middlewares.Deprecated("/v1/tasks"))
+	legacy.POST("/create", CreateTask)
+	legacy.PUT("/update", UpdateTasks)
+	legacy.DELETE("/delete", DeleteTask)
+
+	task := map[string]interface{}{"title": "Test Task", "description": "This is a test task"}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		requestBody    map[string]interface{}
+		expectedStatus int
+		deprecated     bool
+		location       bool
+		shouldContain  string
+	}{
+		{name: "Create", method: "POST", url: "/v1/tasks", requestBody: task, expectedStatus: http.StatusCreated, location: true, shouldContain: `"title":"Test Task"`},
+		{name: "Get", method: "GET", url: "/v1/tasks/42", expectedStatus: http.StatusOK, shouldContain: `"status"`},
+		{name: "Update", method: "PATCH", url: "/v1/tasks/42", requestBody: map[string]interface{}{"title": "Renamed"}, expectedStatus: http.StatusOK, shouldContain: `"title":"Renamed"`},
+		{name: "Delete", method: "DELETE", url: "/v1/tasks/42", expectedStatus: http.StatusNoContent},
+		{name: "Legacy Create", method: "POST", url: "/task/create", requestBody: task, expectedStatus: http.StatusOK, deprecated: true, shouldContain: "Task created successfully"},
+		{name: "Legacy Update", method: "PUT", url: "/task/update?task_id=42", requestBody: map[string]interface{}{"title": "Renamed"}, expectedStatus: http.StatusOK, deprecated: true, shouldContain: "Task updated successfully"},
+		{name: "Legacy Delete", method: "DELETE", url: "/task/delete?task_id=42", expectedStatus: http.StatusOK, deprecated: true, shouldContain: "Task deleted successfully"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := createJSONRequest(tt.method, tt.url, tt.requestBody)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tt.shouldContain)
+
+			if tt.deprecated {
+				assert.Equal(t, "true", recorder.Header().Get("Deprecation"))
+				assert.Equal(t, `</v1/tasks>; rel="successor-version"`, recorder.Header().Get("Link"))
+			} else {
+				assert.Empty(t, recorder.Header().Get("Deprecation"))
+			}
+
+			if tt.location {
+				assert.Regexp(t, `^/v1/tasks/\d+$`, recorder.Header().Get("Location"))
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxHistoryLimit = 200
+
+// historyFields lists the task fields tracked in the history, in the order
+// their changes are recorded.
+var historyFields = []struct {
+	name  string
+	value func(models.Task) string
+}{
+	{"title", func(t models.Task) string { return t.Title }},
+	{"description", func(t models.Task) string { return t.Description }},
+	{"status", func(t models.Task) string { return string(t.Status) }},
+	{"priority", func(t models.Task) string { return string(t.Priority) }},
+	{"start_at", func(t models.Task) string { return formatHistoryTime(t.StartAt) }},
+	{"due_at", func(t models.Task) string { return formatHistoryTime(t.DueAt) }},
+	{"due_timezone", func(t models.Task) string { return t.DueTimezone }},
+	{"parent_id", func(t models.Task) string { return formatHistoryID(t.ParentID) }},
+	{"project_id", func(t models.Task) string { return formatHistoryID(t.ProjectID) }},
+	{"complete_with_subtasks", func(t models.Task) string { return strconv.FormatBool(t.CompleteWithSubtasks) }},
+	{"recurrence", func(t models.Task) string { return t.Recurrence }},
+}
+
+func formatHistoryTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatHistoryID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// taskChanges returns one history entry for every tracked field that differs
+// between the two versions of a task.
+func taskChanges(before, after models.Task, userID uint) []models.TaskHistory {
+	var changes []models.TaskHistory
+	for _, field := range historyFields {
+		old, new := field.value(before), field.value(after)
+		if old == new {
+			continue
+		}
+		changes = append(changes, models.TaskHistory{
+			TaskID:   after.ID,
+			UserID:   userID,
+			Action:   models.HistoryUpdated,
+			Field:    field.name,
+			OldValue: old,
+			NewValue: new,
+		})
+	}
+	return changes
+}
+
+func recordChanges(tx *gorm.DB, before, after models.Task, userID uint) error {
+	changes := taskChanges(before, after, userID)
+	if len(changes) == 0 {
+		return nil
+	}
+	return tx.Create(&changes).Error
+}
+
+func recordHistory(tx *gorm.DB, task models.Task, action models.TaskHistoryAction, userID uint) error {
+	return tx.Create(&models.TaskHistory{TaskID: task.ID, UserID: userID, Action: action}).Error
+}
+
+// historyPage reads the limit and before_id parameters shared by the history
+// endpoints, which page backwards from the newest entry.
+func historyPage(c *gin.Context) (func(*gorm.DB) *gorm.DB, error) {
+	limit := defaultTaskLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxHistoryLimit {
+			return nil, fmt.Errorf("Invalid limit, use a number from 1 to %d", maxHistoryLimit)
+		}
+		limit = parsed
+	}
+
+	var beforeID uint64
+	if value := c.Query("before_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid before_id")
+		}
+		beforeID = parsed
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if beforeID > 0 {
+			db = db.Where("task_history.id < ?", beforeID)
+		}
+		return db.Order("task_history.id DESC").Limit(limit)
+	}, nil
+}
+
+func GetTaskHistory(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	page, err := historyPage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var history []models.TaskHistory
+	err = config.DB.Scopes(page).Where("task_history.task_id = ?", task.ID).Find(&history).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find task history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetActivity is the user's feed of changes to every task they can see,
+// including tasks that were deleted since. user_id narrows it down to the
+// changes one person made.
+func GetActivity(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	page, err := historyPage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	visible := config.DB.Session(&gorm.Session{NewDB: true}).
+		Unscoped().
+		Model(&models.Task{}).
+		Select("tasks.id").
+		Scopes(visibleTasks(userID))
+
+	query := config.DB.Scopes(page).Where("task_history.task_id IN (?)", visible)
+
+	if value := c.Query("user_id"); value != "" {
+		actor, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		query = query.Where("task_history.user_id = ?", actor)
+	}
+
+	var history []models.TaskHistory
+	err = query.Find(&history).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find activity",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"task-manager/config"
+	"task-manager/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskChanges(t *testing.T) {
+	due := time.Date(2024, 6, 1, 9, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	project := uint(3)
+
+	before := models.Task{Title: "Draft", Description: "Old", Status: models.StatusNew, Priority: models.PriorityNormal}
+	before.ID = 42
+
+	tests := []struct {
+		name   string
+		change func(*models.Task)
+		want   []models.TaskHistory
+	}{
+		{
+			name:   "Nothing Changed",
+			change: func(*models.Task) {},
+			want:   nil,
+		},
+		{
+			name: "Description Cleared",
+			change: func(task *models.Task) {
+				task.Description = ""
+			},
+			want: []models.TaskHistory{
+				{TaskID: 42, UserID: 7, Action: models.HistoryUpdated, Field: "description", OldValue: "Old", NewValue: ""},
+			},
+		},
+		{
+			name: "Several Fields",
+			change: func(task *models.Task) {
+				task.Title = "Final"
+				task.DueAt = &due
+				task.ProjectID = &project
+			},
+			want: []models.TaskHistory{
+				{TaskID: 42, UserID: 7, Action: models.HistoryUpdated, Field: "title", OldValue: "Draft", NewValue: "Final"},
+				{TaskID: 42, UserID: 7, Action: models.HistoryUpdated, Field: "due_at", OldValue: "", NewValue: "2024-06-01T07:00:00Z"},
+				{TaskID: 42, UserID: 7, Action: models.HistoryUpdated, Field: "project_id", OldValue: "", NewValue: "3"},
+			},
+		},
+		{
+			name: "Status",
+			change: func(task *models.Task) {
+				task.Status = models.StatusOngoing
+			},
+			want: []models.TaskHistory{
+				{TaskID: 42, UserID: 7, Action: models.HistoryUpdated, Field: "status", OldValue: "new", NewValue: "ongoing"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := before
+			tt.change(&after)
+			assert.Equal(t, tt.want, taskChanges(before, after, 7))
+		})
+	}
+}
+
+func TestTaskHistoryIsImmutable(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	entry := models.TaskHistory{ID: 1, TaskID: 42, UserID: 7, Action: models.HistoryCreated}
+
+	assert.ErrorIs(t, config.DB.Model(&entry).Update("user_id", 8).Error, models.ErrHistoryImmutable)
+	assert.ErrorIs(t, config.DB.Delete(&entry).Error, models.ErrHistoryImmutable)
+}
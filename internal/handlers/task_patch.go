@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/patch"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxPatchSize = 1 << 20
+
+// taskDocument holds the fields of a task that can be patched. Patches are
+// applied to it as JSON and the result is validated before it's saved.
+type taskDocument struct {
+	Title                string              `json:"title"`
+	Description          string              `json:"description"`
+	Status               models.TaskStatus   `json:"status"`
+	Priority             models.TaskPriority `json:"priority"`
+	StartAt              *string             `json:"start_at"`
+	DueAt                *string             `json:"due_at"`
+	DueTimezone          string              `json:"due_timezone"`
+	ParentID             *uint               `json:"parent_id"`
+	ProjectID            *uint               `json:"project_id"`
+	CompleteWithSubtasks bool                `json:"complete_with_subtasks"`
+	Recurrence           string              `json:"recurrence"`
+}
+
+func formatTaskTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	value := t.UTC().Format(time.RFC3339Nano)
+	return &value
+}
+
+func newTaskDocument(task models.Task) taskDocument {
+	return taskDocument{
+		Title:                task.Title,
+		Description:          task.Description,
+		Status:               task.Status,
+		Priority:             task.Priority,
+		StartAt:              formatTaskTime(task.StartAt),
+		DueAt:                formatTaskTime(task.DueAt),
+		DueTimezone:          task.DueTimezone,
+		ParentID:             task.ParentID,
+		ProjectID:            task.ProjectID,
+		CompleteWithSubtasks: task.CompleteWithSubtasks,
+		Recurrence:           task.Recurrence,
+	}
+}
+
+func sameString(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// sameID treats a missing ID and 0 alike, both take the task out.
+func sameID(a, b *uint) bool {
+	var x, y uint
+	if a != nil {
+		x = *a
+	}
+	if b != nil {
+		y = *b
+	}
+	return x == y
+}
+
+// changedTime returns the new value of a changed time for taskScheduleInput,
+// where an empty string clears it.
+func changedTime(before, after *string) *string {
+	if sameString(before, after) {
+		return nil
+	}
+	if after == nil {
+		cleared := ""
+		return &cleared
+	}
+	return after
+}
+
+// applyTo validates the fields that differ between the documents and
+// copies them to the task. The status is left to changeStatus.
+func (doc taskDocument) applyTo(task *models.Task, before taskDocument, userID uint) error {
+	if doc.Title != before.Title {
+		if strings.TrimSpace(doc.Title) == "" {
+			return fmt.Errorf("Title can't be empty")
+		}
+		task.Title = doc.Title
+	}
+
+	task.Description = doc.Description
+
+	if doc.Status != before.Status && !doc.Status.Valid() {
+		return fmt.Errorf("Invalid status")
+	}
+
+	if doc.Priority != before.Priority {
+		if !doc.Priority.Valid() {
+			return fmt.Errorf("Invalid priority")
+		}
+		task.Priority = doc.Priority
+	}
+
+	schedule := taskScheduleInput{
+		StartAt: changedTime(before.StartAt, doc.StartAt),
+		DueAt:   changedTime(before.DueAt, doc.DueAt),
+	}
+	if doc.DueTimezone != before.DueTimezone {
+		schedule.DueTimezone = &doc.DueTimezone
+	}
+	if err := schedule.apply(task); err != nil {
+		return err
+	}
+
+	if !sameID(doc.ParentID, before.ParentID) {
+		task.ParentID = nil
+		if doc.ParentID != nil && *doc.ParentID != 0 {
+			if err := checkParent(userID, task.ID, *doc.ParentID); err != nil {
+				return err
+			}
+			task.ParentID = doc.ParentID
+		}
+	}
+
+	if !sameID(doc.ProjectID, before.ProjectID) {
+		var projectID uint
+		if doc.ProjectID != nil {
+			projectID = *doc.ProjectID
+		}
+		if err := checkProjectChange(*task, userID, projectID); err != nil {
+			return err
+		}
+		task.ProjectID = nil
+		if projectID != 0 {
+			task.ProjectID = doc.ProjectID
+		}
+	}
+
+	task.CompleteWithSubtasks = doc.CompleteWithSubtasks
+
+	if doc.Recurrence != before.Recurrence {
+		recurrence, err := parseRecurrence(doc.Recurrence)
+		if err != nil {
+			return err
+		}
+		task.Recurrence = recurrence
+	}
+
+	return nil
+}
+
+// invalidTaskError is a patch whose result isn't a valid task.
+type invalidTaskError struct {
+	message string
+}
+
+func (e *invalidTaskError) Error() string {
+	return e.message
+}
+
+// patchTask applies the patch to the task and validates the result. The
+// status the patch asks for is returned and left to savePatchedTask.
+func patchTask(task *models.Task, apply func(document, patch []byte) ([]byte, error), body []byte, userID uint) (models.TaskStatus, error) {
+	current := newTaskDocument(*task)
+	document, err := json.Marshal(current)
+	if err != nil {
+		return "", err
+	}
+
+	document, err = apply(document, body)
+	if err != nil {
+		return "", err
+	}
+
+	// The patched document must still be a task
+	var patched taskDocument
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return "", &invalidTaskError{"Patched task is invalid: " + err.Error()}
+	}
+
+	if err := patched.applyTo(task, current, userID); err != nil {
+		return "", &invalidTaskError{err.Error()}
+	}
+
+	return patched.Status, nil
+}
+
+// savePatchedTask saves a task changed by patchTask and moves it to status.
+func savePatchedTask(tx *gorm.DB, task *models.Task, before models.Task, status models.TaskStatus, userID uint) error {
+	if err := saveTask(tx, task); err != nil {
+		return err
+	}
+	if !sameID(before.ParentID, task.ParentID) {
+		if err := touchParents(tx, before, *task); err != nil {
+			return err
+		}
+	}
+	if err := recordChanges(tx, before, *task, userID); err != nil {
+		return err
+	}
+
+	if status != task.Status {
+		return changeStatus(tx, task, status, userID)
+	}
+	return nil
+}
+
+// taskErrorResponse maps the errors of patching and saving a task to a
+// status code and response.
+func taskErrorResponse(err error, task models.Task) (int, gin.H) {
+	var invalid *invalidTaskError
+	var transition *transitionError
+	var blocked *blockedError
+
+	switch {
+	case errors.Is(err, errVersionConflict):
+		return http.StatusPreconditionFailed, gin.H{"error": err.Error(), "version": task.Version}
+	case errors.Is(err, patch.ErrConflict):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	case errors.Is(err, patch.ErrInvalid):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.As(err, &invalid):
+		return http.StatusUnprocessableEntity, gin.H{"error": err.Error()}
+	case errors.As(err, &transition):
+		return http.StatusConflict, gin.H{"error": err.Error(), "allowed": transition.Allowed}
+	case errors.As(err, &blocked):
+		return http.StatusConflict, gin.H{"error": err.Error(), "blockers": blocked.Blockers}
+	default:
+		return http.StatusBadRequest, gin.H{"error": "Failed to update task"}
+	}
+}
+
+// PatchTask updates a task with a JSON Merge Patch (RFC 7396) or a JSON Patch
+// (RFC 6902). Unlike UpdateTasks, fields can be cleared.
+func PatchTask(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok || !checkIfMatch(c, task) {
+		return
+	}
+	before := task
+
+	var apply func(document, patch []byte) ([]byte, error)
+	switch c.ContentType() {
+	case "application/merge-patch+json", "application/json":
+		apply = patch.Merge
+	case "application/json-patch+json":
+		apply = patch.Apply
+	default:
+		c.Header("Accept-Patch", "application/merge-patch+json, application/json-patch+json")
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Use application/merge-patch+json or application/json-patch+json",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Patch is too large",
+		})
+		return
+	}
+
+	status, err := patchTask(&task, apply, body, userID)
+	if err == nil {
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := savePatchedTask(tx, &task, before, status, userID); err != nil {
+				return err
+			}
+
+			// Assignees have to belong to the task's new project
+			if !sameID(task.ProjectID, before.ProjectID) {
+				return pruneAssignees(tx, &task)
+			}
+			return nil
+		})
+	}
+
+	if err != nil {
+		if errors.Is(err, errVersionConflict) {
+			c.Header("ETag", taskETag(before))
+		}
+		c.JSON(taskErrorResponse(err, before))
+		return
+	}
+
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestPatchTask(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	// A dry run loads an empty task created by user 0, so the caller owns it
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(0))
+	})
+	router.PATCH("/v1/tasks/:id", PatchTask)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		shouldContain  string
+	}{
+		{
+			name:           "Merge Patch",
+			contentType:    "application/merge-patch+json",
+			body:           `{"title":"Renamed","description":null,"priority":"high"}`,
+			expectedStatus: http.StatusOK,
+			shouldContain:  `"title":"Renamed"`,
+		},
+		{
+			name:           "Plain JSON Is A Merge Patch",
+			contentType:    "application/json",
+			body:           `{"due_at":"2024-05-03T17:00:00Z","due_timezone":"Europe/Kyiv"}`,
+			expectedStatus: http.StatusOK,
+			shouldContain:  `"due_at":"2024-05-03T17:00:00Z"`,
+		},
+		{
+			name:           "JSON Patch",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op":"test","path":"/title","value":""},{"op":"replace","path":"/title","value":"Renamed"}]`,
+			expectedStatus: http.StatusOK,
+			shouldContain:  `"title":"Renamed"`,
+		},
+		{
+			name:           "Failed Test",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op":"test","path":"/title","value":"Other"}]`,
+			expectedStatus: http.StatusConflict,
+			shouldContain:  "test failed",
+		},
+		{
+			name:           "Missing Path",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op":"remove","path":"/labels"}]`,
+			expectedStatus: http.StatusConflict,
+			shouldContain:  "not found",
+		},
+		{
+			name:           "Malformed Patch",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op":"add"`,
+			expectedStatus: http.StatusBadRequest,
+			shouldContain:  "invalid patch",
+		},
+		{
+			name:           "Unknown Field",
+			contentType:    "application/merge-patch+json",
+			body:           `{"created_by":7}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			shouldContain:  "created_by",
+		},
+		{
+			name:           "Blank Title",
+			contentType:    "application/merge-patch+json",
+			body:           `{"title":"  "}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			shouldContain:  "Title can't be empty",
+		},
+		{
+			name:           "Invalid Priority",
+			contentType:    "application/merge-patch+json",
+			body:           `{"priority":"whenever"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			shouldContain:  "Invalid priority",
+		},
+		{
+			name:           "Invalid Recurrence",
+			contentType:    "application/merge-patch+json",
+			body:           `{"recurrence":"FREQ=HOURLY"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			shouldContain:  "Invalid recurrence",
+		},
+		{
+			name:           "Unsupported Media Type",
+			contentType:    "text/plain",
+			body:           `title=Renamed`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			shouldContain:  "merge-patch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("PATCH", "/v1/tasks/42", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tt.shouldContain)
+		})
+	}
+}
+
+func TestMovingTaskBetweenProjects(t *testing.T) {
+	teamProject := uint(1)
+
+	tests := []struct {
+		name           string
+		taskProject    *uint
+		role           models.TeamRole
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Member Moves To Another Team", taskProject: &teamProject, role: models.RoleMember, method: "PATCH", url: "/v1/tasks/42", body: `{"project_id":2}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Member Takes Task Out Of Team", taskProject: &teamProject, role: models.RoleMember, method: "PATCH", url: "/v1/tasks/42", body: `{"project_id":null}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Editor Moves Personal Task Into Team", method: "PATCH", url: "/v1/tasks/42", body: `{"project_id":2}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Member Moves With Legacy Update", taskProject: &teamProject, role: models.RoleMember, method: "PUT", url: "/task/update?task_id=42", body: `{"project_id":2}`, expectedStatus: http.StatusBadRequest},
+		{name: "Admin Moves To Another Team", taskProject: &teamProject, role: models.RoleAdmin, method: "PATCH", url: "/v1/tasks/42", body: `{"project_id":2}`, expectedStatus: http.StatusOK},
+		{name: "Member Edits Without Moving", taskProject: &teamProject, role: models.RoleMember, method: "PATCH", url: "/v1/tasks/42", body: `{"title":"Renamed"}`, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			// User 7 can edit a task of user 1, in project 1 of team 1 when
+			// it has a project, and is a member of team 2 owning project 2
+			onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+				if task, ok := tx.Statement.Dest.(*models.Task); ok {
+					task.CreatedBy = 1
+					task.ProjectID = tt.taskProject
+				}
+			})
+			for id := uint(1); id <= 2; id++ {
+				teamID := id
+				onQuery(fmt.Sprintf("projects.id = %d", id), func(tx *gorm.DB) {
+					if project, ok := tx.Statement.Dest.(*models.Project); ok {
+						project.ID = teamID
+						project.TeamID = &teamID
+					}
+				})
+			}
+			onQuery("team_id = 1 AND user_id = 7", func(tx *gorm.DB) {
+				if membership, ok := tx.Statement.Dest.(*models.Membership); ok {
+					membership.Role = tt.role
+				}
+			})
+			onQuery("team_id = 2 AND user_id = 7", func(tx *gorm.DB) {
+				if membership, ok := tx.Statement.Dest.(*models.Membership); ok {
+					membership.Role = models.RoleMember
+				}
+			})
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+			})
+			router.PATCH("/v1/tasks/:id", PatchTask)
+			router.PUT("/task/update", UpdateTasks)
+
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedStatus != http.StatusOK {
+				assert.Contains(t, recorder.Body.String(), "You don't have permission to move this task to another project")
+				assert.NotContains(t, strings.Join(*statements, "\n"), "UPDATE tasks")
+			}
+		})
+	}
+}
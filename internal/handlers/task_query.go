@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"task-manager/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultTaskLimit = 50
+	maxTaskLimit     = 200
+)
+
+type taskSortField struct {
+	expr  func(asOf time.Time) clause.Expr
+	value func(models.Task) interface{}
+	parse func(string) (interface{}, error)
+}
+
+func sortColumn(column string) func(time.Time) clause.Expr {
+	return func(time.Time) clause.Expr {
+		return clause.Expr{SQL: column}
+	}
+}
+
+func parseCursorTime(value string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func parseCursorString(value string) (interface{}, error) {
+	return value, nil
+}
+
+func parseCursorFloat(value string) (interface{}, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+var taskSortFields = map[string]taskSortField{
+	"created_at": {
+		expr:  sortColumn("tasks.created_at"),
+		value: func(t models.Task) interface{} { return t.CreatedAt },
+		parse: parseCursorTime,
+	},
+	"updated_at": {
+		expr:  sortColumn("tasks.updated_at"),
+		value: func(t models.Task) interface{} { return t.UpdatedAt },
+		parse: parseCursorTime,
+	},
+	"status_changed_at": {
+		expr:  sortColumn("tasks.status_changed_at"),
+		value: func(t models.Task) interface{} { return t.StatusChangedAt },
+		parse: parseCursorTime,
+	},
+	"title": {
+		expr:  sortColumn("tasks.title"),
+		value: func(t models.Task) interface{} { return t.Title },
+		parse: parseCursorString,
+	},
+	"status": {
+		expr:  sortColumn("tasks.status"),
+		value: func(t models.Task) interface{} { return string(t.Status) },
+		parse: parseCursorString,
+	},
+	"urgency": {
+		expr:  urgencyExpr,
+		value: func(t models.Task) interface{} { return t.Urgency },
+		parse: parseCursorFloat,
+	},
+}
+
+// taskCursor points just past the last task of a page. It remembers the sort
+// it was made for so it can't be replayed against a different ordering, and
+// the time computed scores were taken at so they stay the same across pages.
+type taskCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d"`
+	Value string    `json:"v"`
+	ID    uint      `json:"id"`
+	AsOf  time.Time `json:"t"`
+}
+
+func encodeCursor(cursor taskCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (taskCursor, error) {
+	var cursor taskCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
+
+type taskQuery struct {
+	userID        uint
+	ids           []string
+	statuses      []models.TaskStatus
+	priorities    []models.TaskPriority
+	labels        []string
+	allLabels     bool
+	createdAfter  *time.Time
+	createdBefore *time.Time
+	createdBy     *uint
+	projectID     *uint
+	assignee      *uint
+	text          string
+	sort          string
+	desc          bool
+	limit         int
+	cursor        *taskCursor
+	cursorValue   interface{}
+	asOf          time.Time
+}
+
+func parseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func parseTaskQuery(params url.Values, userID uint) (taskQuery, error) {
+	q := taskQuery{
+		userID: userID,
+		sort:   "created_at",
+		desc:   true,
+		limit:  defaultTaskLimit,
+		text:   strings.TrimSpace(params.Get("q")),
+		asOf:   time.Now(),
+	}
+
+	if id := params.Get("task_id"); id != "" {
+		q.ids = []string{id}
+	}
+
+	for _, status := range params["status"] {
+		if !models.TaskStatus(status).Valid() {
+			return q, fmt.Errorf("Invalid status %q", status)
+		}
+		q.statuses = append(q.statuses, models.TaskStatus(status))
+	}
+
+	for _, priority := range params["priority"] {
+		if !models.TaskPriority(priority).Valid() {
+			return q, fmt.Errorf("Invalid priority %q", priority)
+		}
+		q.priorities = append(q.priorities, models.TaskPriority(priority))
+	}
+
+	for _, label := range params["label"] {
+		if !slices.Contains(q.labels, label) {
+			q.labels = append(q.labels, label)
+		}
+	}
+	switch params.Get("label_match") {
+	case "", "all":
+		q.allLabels = true
+	case "any":
+	default:
+		return q, fmt.Errorf("Invalid label_match, use all or any")
+	}
+
+	if value := params.Get("created_after"); value != "" {
+		t, err := parseQueryTime(value)
+		if err != nil {
+			return q, fmt.Errorf("Invalid created_after, use RFC 3339 or YYYY-MM-DD")
+		}
+		q.createdAfter = &t
+	}
+
+	if value := params.Get("created_before"); value != "" {
+		t, err := parseQueryTime(value)
+		if err != nil {
+			return q, fmt.Errorf("Invalid created_before, use RFC 3339 or YYYY-MM-DD")
+		}
+		q.createdBefore = &t
+	}
+
+	if value := params.Get("created_by"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("Invalid created_by")
+		}
+		creator := uint(id)
+		q.createdBy = &creator
+	}
+
+	if value := params.Get("project_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("Invalid project_id")
+		}
+		project := uint(id)
+		q.projectID = &project
+	}
+
+	if value := params.Get("assignee"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("Invalid assignee")
+		}
+		assignee := uint(id)
+		q.assignee = &assignee
+	}
+
+	if value := params.Get("sort"); value != "" {
+		if _, ok := taskSortFields[value]; !ok {
+			return q, fmt.Errorf("Invalid sort field %q", value)
+		}
+		q.sort = value
+	}
+
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.desc = false
+	default:
+		return q, fmt.Errorf("Invalid order, use asc or desc")
+	}
+
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxTaskLimit {
+			return q, fmt.Errorf("Invalid limit, use a number from 1 to %d", maxTaskLimit)
+		}
+		q.limit = limit
+	}
+
+	if value := params.Get("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil || cursor.Sort != q.sort || cursor.Desc != q.desc {
+			return q, fmt.Errorf("Invalid cursor")
+		}
+
+		cursorValue, err := taskSortFields[q.sort].parse(cursor.Value)
+		if err != nil {
+			return q, fmt.Errorf("Invalid cursor")
+		}
+
+		q.cursor = &cursor
+		q.cursorValue = cursorValue
+		q.asOf = cursor.AsOf
+	}
+
+	return q, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// scope applies the filters, the ordering and the page window to a task query.
+// One extra row is fetched so nextCursor can tell whether another page exists.
+func (q taskQuery) scope(db *gorm.DB) *gorm.DB {
+	if len(q.ids) > 0 {
+		db = db.Where("tasks.id IN ?", q.ids)
+	}
+	if len(q.statuses) > 0 {
+		db = db.Where("tasks.status IN ?", q.statuses)
+	}
+	if len(q.priorities) > 0 {
+		db = db.Where("tasks.priority IN ?", q.priorities)
+	}
+	if len(q.labels) > 0 {
+		labelled := db.Session(&gorm.Session{NewDB: true}).
+			Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.name IN ?", q.labels).
+			Scopes(visibleLabels(q.userID))
+		if q.allLabels {
+			labelled = labelled.Group("task_labels.task_id").Having("COUNT(DISTINCT labels.name) = ?", len(q.labels))
+		}
+		db = db.Where("tasks.id IN (?)", labelled)
+	}
+	if q.createdAfter != nil {
+		db = db.Where("tasks.created_at >= ?", *q.createdAfter)
+	}
+	if q.createdBefore != nil {
+		db = db.Where("tasks.created_at < ?", *q.createdBefore)
+	}
+	if q.createdBy != nil {
+		db = db.Where("tasks.created_by = ?", *q.createdBy)
+	}
+	if q.projectID != nil {
+		db = db.Where("tasks.project_id = ?", *q.projectID)
+	}
+	if q.assignee != nil {
+		assigned := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.TaskAssignee{}).
+			Select("task_id").
+			Where("user_id = ?", *q.assignee)
+		db = db.Where("tasks.id IN (?)", assigned)
+	}
+	if q.text != "" {
+		pattern := "%" + escapeLike(q.text) + "%"
+		db = db.Where("tasks.title ILIKE ? OR tasks.description ILIKE ?", pattern, pattern)
+	}
+
+	expr := taskSortFields[q.sort].expr(q.asOf)
+	direction := "ASC"
+	comparison := ">"
+	if q.desc {
+		direction = "DESC"
+		comparison = "<"
+	}
+
+	if q.cursor != nil {
+		db = db.Where(fmt.Sprintf("(?, tasks.id) %s (?, ?)", comparison), expr, q.cursorValue, q.cursor.ID)
+	}
+
+	order := clause.OrderBy{Expression: clause.Expr{
+		SQL:  fmt.Sprintf("? %s, tasks.id %s", direction, direction),
+		Vars: []interface{}{expr},
+	}}
+
+	return db.
+		Scopes(withComputedFields(q.asOf)).
+		Clauses(order).
+		Limit(q.limit + 1)
+}
+
+// page trims the extra row fetched by scope and returns the cursor for the
+// following page, or an empty string on the last page.
+func (q taskQuery) page(tasks []models.Task) ([]models.Task, string) {
+	if len(tasks) <= q.limit {
+		return tasks, ""
+	}
+
+	tasks = tasks[:q.limit]
+	last := tasks[len(tasks)-1]
+
+	value := taskSortFields[q.sort].value(last)
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339Nano)
+	}
+
+	return tasks, encodeCursor(taskCursor{
+		Sort:  q.sort,
+		Desc:  q.desc,
+		Value: fmt.Sprint(value),
+		ID:    last.ID,
+		AsOf:  q.asOf,
+	})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/url"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTaskQuery(t *testing.T) {
+	validCursor := encodeCursor(taskCursor{Sort: "title", Desc: false, Value: "Alpha", ID: 3})
+
+	tests := []struct {
+		name        string
+		query       string
+		expectError bool
+	}{
+		{name: "Defaults", query: ""},
+		{name: "All Filters", query: "status=new&status=ongoing&created_after=2024-01-01&created_before=2024-02-01T00:00:00Z&created_by=3&assignee=4&q=report&sort=title&order=asc&limit=10"},
+		{name: "Cursor Matches Sort", query: "sort=title&order=asc&cursor=" + validCursor},
+		{name: "Invalid Status", query: "status=done", expectError: true},
+		{name: "Invalid Date", query: "created_after=yesterday", expectError: true},
+		{name: "Invalid Creator", query: "created_by=me", expectError: true},
+		{name: "Invalid Assignee", query: "assignee=me", expectError: true},
+		{name: "Invalid Sort", query: "sort=password", expectError: true},
+		{name: "Invalid Order", query: "order=up", expectError: true},
+		{name: "Limit Too Large", query: "limit=1000", expectError: true},
+		{name: "Garbage Cursor", query: "cursor=not-a-cursor", expectError: true},
+		{name: "Cursor For Another Sort", query: "cursor=" + validCursor, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := url.ParseQuery(tt.query)
+			assert.NoError(t, err)
+
+			_, err = parseTaskQuery(params, 1)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTaskQueryPage(t *testing.T) {
+	params, _ := url.ParseQuery("limit=2")
+	query, err := parseTaskQuery(params, 1)
+	assert.NoError(t, err)
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC)
+	tasks := []models.Task{{}, {}, {}}
+	for i := range tasks {
+		tasks[i].ID = uint(10 - i)
+		tasks[i].CreatedAt = created.Add(-time.Duration(i) * time.Hour)
+	}
+
+	// Extra row means there is another page
+	page, next := query.page(tasks)
+	assert.Len(t, page, 2)
+	assert.NotEmpty(t, next)
+
+	cursor, err := decodeCursor(next)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), cursor.ID)
+	assert.Equal(t, "created_at", cursor.Sort)
+
+	// The cursor is accepted for the same ordering and resumes after task 9
+	params.Set("cursor", next)
+	query, err = parseTaskQuery(params, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Add(-time.Hour), query.cursorValue)
+
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+	config.DB.Scopes(query.scope).Find(&[]models.Task{})
+	assert.Contains(t, (*statements)[0], "(tasks.created_at, tasks.id) < ('2024-05-01 11:00:00.123', 9)")
+	assert.Contains(t, (*statements)[0], "ORDER BY tasks.created_at DESC, tasks.id DESC LIMIT 3")
+
+	// Last page has no cursor
+	page, next = query.page(tasks[:2])
+	assert.Len(t, page, 2)
+	assert.Empty(t, next)
+}
+
+func TestUrgencyCursorKeepsScoreTime(t *testing.T) {
+	params, _ := url.ParseQuery("sort=urgency&priority=high&priority=urgent&limit=1")
+	query, err := parseTaskQuery(params, 1)
+	assert.NoError(t, err)
+
+	tasks := []models.Task{{Urgency: 61.123456789}, {Urgency: 12}}
+	tasks[0].ID = 4
+
+	_, next := query.page(tasks)
+	params.Set("cursor", next)
+
+	resumed, err := parseTaskQuery(params, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 61.123456789, resumed.cursorValue)
+	assert.True(t, query.asOf.Equal(resumed.asOf))
+
+	params.Set("priority", "critical")
+	_, err = parseTaskQuery(params, 1)
+	assert.Error(t, err)
+}
+
+func TestTaskQueryLabelMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		expectSQL    string
+		expectHaving bool
+	}{
+		{
+			name:         "All Labels By Default",
+			query:        "label=bug&label=backend&label=bug",
+			expectSQL:    "labels.name IN ('bug','backend')",
+			expectHaving: true,
+		},
+		{
+			name:      "Any Label",
+			query:     "label=bug&label=backend&label_match=any",
+			expectSQL: "labels.name IN ('bug','backend')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := url.ParseQuery(tt.query)
+			query, err := parseTaskQuery(params, 1)
+			assert.NoError(t, err)
+
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+			config.DB.Scopes(query.scope).Find(&[]models.Task{})
+
+			sql := (*statements)[len(*statements)-1]
+			assert.Contains(t, sql, tt.expectSQL)
+			// Label names only match the user's own and their teams' labels
+			assert.Contains(t, sql, "AND (labels.owner_id = 1 OR labels.team_id IN (SELECT")
+			if tt.expectHaving {
+				assert.Contains(t, sql, "HAVING COUNT(DISTINCT labels.name) = 2")
+			} else {
+				assert.NotContains(t, sql, "HAVING")
+			}
+		})
+	}
+
+	params, _ := url.ParseQuery("label=bug&label_match=none")
+	_, err := parseTaskQuery(params, 1)
+	assert.Error(t, err)
+}
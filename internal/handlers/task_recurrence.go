@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/recurrence"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseRecurrence(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	if _, err := recurrence.Parse(value); err != nil {
+		return "", fmt.Errorf("Invalid recurrence: %s", err.Error())
+	}
+	return value, nil
+}
+
+// seriesStart is when the first occurrence of the task's series was due.
+func seriesStart(tx *gorm.DB, task *models.Task) *time.Time {
+	if task.SeriesID == nil || *task.SeriesID == task.ID {
+		return task.DueAt
+	}
+
+	var first models.Task
+	err := tx.Unscoped().Select("id", "due_at").First(&first, *task.SeriesID).Error
+	if err != nil || first.DueAt == nil {
+		return task.DueAt
+	}
+	return first.DueAt
+}
+
+// nextDueAt works out when the occurrence after a task completed at the given
+// time is due. start is when the series began. Occurrences missed while the
+// task was late are skipped.
+func nextDueAt(rule recurrence.Rule, task *models.Task, start *time.Time, completedAt time.Time) time.Time {
+	loc, err := time.LoadLocation(task.DueTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	completedAt = completedAt.In(loc)
+
+	anchor := completedAt
+	if task.DueAt != nil {
+		due := task.DueAt.In(loc)
+		if rule.FromCompletion {
+			// Keep the time of day the task used to be due at
+			anchor = time.Date(completedAt.Year(), completedAt.Month(), completedAt.Day(),
+				due.Hour(), due.Minute(), due.Second(), 0, loc)
+		} else {
+			anchor = due
+		}
+	}
+
+	// Counting from completion starts a new series every time
+	first := anchor
+	if start != nil && !rule.FromCompletion {
+		first = start.In(loc)
+	}
+
+	next := rule.Next(first, anchor)
+	for !next.After(completedAt) {
+		next = rule.Next(first, next)
+	}
+	return next.UTC()
+}
+
+// scheduleNextOccurrence creates the next occurrence of a recurring task that
+// was just completed. Occurrences are linked to the previous one and to the
+// first task of the series, and completing a reopened occurrence again doesn't
+// create a second follow-up.
+func scheduleNextOccurrence(tx *gorm.DB, task *models.Task, userID uint) error {
+	if task.Recurrence == "" {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return nil
+	}
+
+	var existing int64
+	err = tx.Unscoped().Model(&models.Task{}).Where("previous_occurrence_id = ?", task.ID).Count(&existing).Error
+	if err != nil || existing > 0 {
+		return err
+	}
+
+	if task.SeriesID == nil {
+		err := tx.Model(&models.Task{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+			"series_id": task.ID,
+			"version":   gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		task.SeriesID = &task.ID
+		task.Version++
+	}
+
+	now := time.Now()
+	dueAt := nextDueAt(rule, task, seriesStart(tx, task), now)
+
+	next := models.Task{
+		Title:                task.Title,
+		Description:          task.Description,
+		CreatedBy:            task.CreatedBy,
+		Date:                 now,
+		Status:               models.StatusNew,
+		StatusChangedAt:      now,
+		DueAt:                &dueAt,
+		DueTimezone:          task.DueTimezone,
+		Priority:             task.Priority,
+		ParentID:             task.ParentID,
+		ProjectID:            task.ProjectID,
+		CompleteWithSubtasks: task.CompleteWithSubtasks,
+		Recurrence:           task.Recurrence,
+		SeriesID:             task.SeriesID,
+		PreviousOccurrenceID: &task.ID,
+	}
+
+	// Keep the same lead time between start and due
+	if task.StartAt != nil && task.DueAt != nil {
+		startAt := dueAt.Add(task.StartAt.Sub(*task.DueAt))
+		next.StartAt = &startAt
+	}
+
+	if err := tx.Model(task).Association("Labels").Find(&next.Labels); err != nil {
+		return err
+	}
+
+	if err := createTask(tx, &next, userID); err != nil {
+		return err
+	}
+
+	var items []models.ChecklistItem
+	if err := tx.Where("task_id = ?", task.ID).Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		copied := models.ChecklistItem{TaskID: next.ID, Text: item.Text, Position: item.Position}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	var shares []models.TaskShare
+	if err := tx.Where("task_id = ?", task.ID).Find(&shares).Error; err != nil {
+		return err
+	}
+	for _, share := range shares {
+		copied := models.TaskShare{TaskID: next.ID, UserID: share.UserID, CanEdit: share.CanEdit}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	var assignees []models.TaskAssignee
+	if err := tx.Where("task_id = ?", task.ID).Find(&assignees).Error; err != nil {
+		return err
+	}
+	for _, assignee := range assignees {
+		copied := models.TaskAssignee{TaskID: next.ID, UserID: assignee.UserID, AssignedBy: assignee.AssignedBy}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetOccurrences(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	seriesID := task.ID
+	if task.SeriesID != nil {
+		seriesID = *task.SeriesID
+	}
+
+	var tasks []models.Task
+	err := config.DB.Scopes(visibleTasks(userID), withComputedFields(time.Now())).
+		Where("tasks.id = ? OR tasks.series_id = ?", seriesID, seriesID).
+		Order("tasks.created_at, tasks.id").
+		Find(&tasks).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find occurrences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
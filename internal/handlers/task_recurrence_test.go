@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/recurrence"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNextDueAt(t *testing.T) {
+	due := time.Date(2024, 3, 4, 17, 0, 0, 0, time.UTC) // Monday
+	completed := time.Date(2024, 3, 5, 10, 15, 0, 0, time.UTC)
+	started := time.Date(2024, 1, 31, 17, 0, 0, 0, time.UTC)
+	clamped := time.Date(2024, 2, 29, 17, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		rule        string
+		dueAt       *time.Time
+		start       *time.Time
+		timezone    string
+		completedAt time.Time
+		want        time.Time
+	}{
+		{
+			name:        "Weekly From Due Time",
+			rule:        "FREQ=WEEKLY;BYDAY=MO",
+			dueAt:       &due,
+			completedAt: completed,
+			want:        time.Date(2024, 3, 11, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Missed Occurrences Are Skipped",
+			rule:        "FREQ=DAILY",
+			dueAt:       &due,
+			completedAt: time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC),
+			want:        time.Date(2024, 3, 8, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Days After Completion Keep Time Of Day",
+			rule:        "FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION",
+			dueAt:       &due,
+			completedAt: completed,
+			want:        time.Date(2024, 3, 8, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "No Due Time",
+			rule:        "FREQ=DAILY;INTERVAL=2",
+			completedAt: completed,
+			want:        time.Date(2024, 3, 7, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:        "Monthly Returns To Series Day",
+			rule:        "FREQ=MONTHLY",
+			dueAt:       &clamped,
+			start:       &started,
+			completedAt: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+			want:        time.Date(2024, 3, 31, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Weekday In Task Time Zone",
+			rule:        "FREQ=WEEKLY;BYDAY=TU",
+			dueAt:       &due, // Tuesday 02:00 in Tokyo
+			timezone:    "Asia/Tokyo",
+			completedAt: completed,
+			want:        time.Date(2024, 3, 11, 17, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := recurrence.Parse(tt.rule)
+			assert.NoError(t, err)
+
+			task := models.Task{DueAt: tt.dueAt, DueTimezone: tt.timezone}
+			assert.Equal(t, tt.want, nextDueAt(rule, &task, tt.start, tt.completedAt))
+		})
+	}
+}
+
+func TestScheduleNextOccurrence(t *testing.T) {
+	due := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	seriesID := uint(5)
+
+	tests := []struct {
+		name           string
+		seriesID       *uint
+		followedUp     bool
+		expectedSeries uint
+	}{
+		{name: "Starts A Series", expectedSeries: 9},
+		{name: "Continues A Series", seriesID: &seriesID, expectedSeries: 5},
+		{name: "Reopened Occurrence", followedUp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			if tt.followedUp {
+				onQuery("previous_occurrence_id = 9", func(tx *gorm.DB) {
+					if count, ok := tx.Statement.Dest.(*int64); ok {
+						*count = 1
+						tx.RowsAffected = 1
+					}
+				})
+			}
+			onQuery("FROM labels", func(tx *gorm.DB) {
+				if labels, ok := tx.Statement.Dest.(*[]models.Label); ok {
+					label := models.Label{Name: "Garden"}
+					label.ID = 3
+					*labels = []models.Label{label}
+				}
+			})
+			onQuery("FROM checklist_items", func(tx *gorm.DB) {
+				if items, ok := tx.Statement.Dest.(*[]models.ChecklistItem); ok {
+					*items = []models.ChecklistItem{{TaskID: 9, Text: "Water the plants", Position: 2}}
+				}
+			})
+			onQuery("FROM task_shares", func(tx *gorm.DB) {
+				if shares, ok := tx.Statement.Dest.(*[]models.TaskShare); ok {
+					*shares = []models.TaskShare{{TaskID: 9, UserID: 8, CanEdit: true}}
+				}
+			})
+
+			// A dry run inserts nothing, so hand out the ID of the next occurrence
+			var created []models.Task
+			config.DB.Callback().Create().After("gorm:create").Before("gorm:save_after_associations").Register("test:task_id", func(tx *gorm.DB) {
+				if task, ok := tx.Statement.Dest.(*models.Task); ok {
+					task.ID = 10
+					created = append(created, *task)
+				}
+			})
+
+			task := models.Task{
+				Title:      "Water the plants",
+				CreatedBy:  7,
+				Status:     models.StatusCompleted,
+				DueAt:      &due,
+				Recurrence: "FREQ=DAILY",
+				SeriesID:   tt.seriesID,
+			}
+			task.ID = 9
+			assert.NoError(t, scheduleNextOccurrence(config.DB, &task, 7))
+
+			all := strings.Join(*statements, "\n")
+			if tt.followedUp {
+				assert.Empty(t, created)
+				assert.NotContains(t, all, "INSERT INTO")
+				return
+			}
+
+			assert.Len(t, created, 1)
+			next := created[0]
+			assert.Equal(t, tt.expectedSeries, *next.SeriesID)
+			assert.Equal(t, uint(9), *next.PreviousOccurrenceID)
+			assert.Equal(t, due.Add(24*time.Hour), *next.DueAt)
+			assert.Equal(t, models.StatusNew, next.Status)
+
+			assert.Contains(t, all, "INSERT INTO task_labels (task_id,label_id) VALUES (10,3)")
+			assert.Contains(t, all, "INSERT INTO checklist_items")
+			assert.Contains(t, all, "10,'Water the plants',false,2)")
+			assert.Contains(t, all, "INSERT INTO task_shares")
+			assert.Contains(t, all, "10,8,true)")
+			if tt.seriesID == nil {
+				assert.Contains(t, all, "UPDATE tasks SET series_id=9")
+			} else {
+				assert.NotContains(t, all, "SET series_id")
+			}
+		})
+	}
+}
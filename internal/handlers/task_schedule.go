@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var localTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", time.DateOnly}
+
+// parseTaskTime reads an RFC 3339 timestamp, or a local time without an
+// offset such as "2024-05-01T17:00" which is taken in the given time zone.
+func parseTaskTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid time %q, use RFC 3339 or YYYY-MM-DDTHH:MM", value)
+}
+
+type taskScheduleInput struct {
+	StartAt     *string `json:"start_at"`
+	DueAt       *string `json:"due_at"`
+	DueTimezone *string `json:"due_timezone"`
+}
+
+// apply copies the provided schedule fields to the task. Fields left out keep
+// their value and empty strings clear them.
+func (in taskScheduleInput) apply(task *models.Task) error {
+	if in.DueTimezone != nil {
+		if _, err := time.LoadLocation(*in.DueTimezone); err != nil {
+			return fmt.Errorf("Invalid time zone %q", *in.DueTimezone)
+		}
+		task.DueTimezone = *in.DueTimezone
+	}
+
+	loc, err := time.LoadLocation(task.DueTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	if in.StartAt != nil {
+		task.StartAt = nil
+		if *in.StartAt != "" {
+			startAt, err := parseTaskTime(*in.StartAt, loc)
+			if err != nil {
+				return err
+			}
+			task.StartAt = &startAt
+		}
+	}
+
+	if in.DueAt != nil {
+		task.DueAt = nil
+		if *in.DueAt != "" {
+			dueAt, err := parseTaskTime(*in.DueAt, loc)
+			if err != nil {
+				return err
+			}
+			task.DueAt = &dueAt
+		}
+
+		// A new due time deserves a new reminder
+		task.RemindedAt = nil
+	}
+
+	if task.StartAt != nil && task.DueAt != nil && task.StartAt.After(*task.DueAt) {
+		return fmt.Errorf("Start time must be before the due time")
+	}
+
+	return nil
+}
+
+func GetOverdueTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	now := time.Now()
+
+	var tasks []models.Task
+	err := config.DB.Scopes(visibleTasks(userID), withComputedFields(now)).
+		Where("tasks.due_at < ?", now).
+		Where("tasks.status NOT IN ?", models.ClosedTaskStatuses).
+		Order("tasks.due_at").
+		Find(&tasks).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+func GetUpcomingTasks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	within, err := time.ParseDuration(c.DefaultQuery("within", "24h"))
+	if err != nil || within <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid within, use a duration such as 72h",
+		})
+		return
+	}
+
+	now := time.Now()
+
+	var tasks []models.Task
+	err = config.DB.Scopes(visibleTasks(userID), withComputedFields(now)).
+		Where("tasks.due_at >= ? AND tasks.due_at <= ?", now, now.Add(within)).
+		Where("tasks.status NOT IN ?", models.ClosedTaskStatuses).
+		Order("tasks.due_at").
+		Find(&tasks).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
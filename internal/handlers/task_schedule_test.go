@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"task-manager/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskScheduleInput(t *testing.T) {
+	str := func(value string) *string { return &value }
+	reminded := time.Now()
+
+	tests := []struct {
+		name        string
+		input       taskScheduleInput
+		expectDue   string
+		expectError bool
+	}{
+		{
+			name:      "RFC 3339 Due Time",
+			input:     taskScheduleInput{DueAt: str("2024-05-01T17:00:00+02:00")},
+			expectDue: "2024-05-01T15:00:00Z",
+		},
+		{
+			name:      "Local Due Time In Time Zone",
+			input:     taskScheduleInput{DueAt: str("2024-05-01T17:00"), DueTimezone: str("America/New_York")},
+			expectDue: "2024-05-01T21:00:00Z",
+		},
+		{
+			name:      "Date Only Defaults To UTC",
+			input:     taskScheduleInput{DueAt: str("2024-05-01")},
+			expectDue: "2024-05-01T00:00:00Z",
+		},
+		{
+			name:  "Empty String Clears Due Time",
+			input: taskScheduleInput{DueAt: str("")},
+		},
+		{
+			name:        "Unknown Time Zone",
+			input:       taskScheduleInput{DueTimezone: str("Mars/Olympus")},
+			expectError: true,
+		},
+		{
+			name:        "Invalid Due Time",
+			input:       taskScheduleInput{DueAt: str("next friday")},
+			expectError: true,
+		},
+		{
+			name:        "Start After Due",
+			input:       taskScheduleInput{StartAt: str("2024-05-02"), DueAt: str("2024-05-01")},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := models.Task{RemindedAt: &reminded}
+
+			err := tt.input.apply(&task)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Nil(t, task.RemindedAt)
+			if tt.expectDue == "" {
+				assert.Nil(t, task.DueAt)
+			} else {
+				assert.Equal(t, tt.expectDue, task.DueAt.Format(time.RFC3339))
+			}
+		})
+	}
+}
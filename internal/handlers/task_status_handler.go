@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type transitionError struct {
+	From    models.TaskStatus
+	To      models.TaskStatus
+	Allowed []models.TaskStatus
+}
+
+func (e *transitionError) Error() string {
+	return fmt.Sprintf("Task can't move from %s to %s", e.From, e.To)
+}
+
+// changeStatus moves the task to a new status and records when it was entered.
+func changeStatus(tx *gorm.DB, task *models.Task, to models.TaskStatus, userID uint) error {
+	if !config.Workflow.Allowed(task.Status, to) {
+		return &transitionError{From: task.Status, To: to, Allowed: config.Workflow.Next(task.Status)}
+	}
+
+	if to == models.StatusCompleted {
+		if err := checkBlockers(tx, task); err != nil {
+			return err
+		}
+	}
+
+	before := *task
+	change := models.TaskStatusChange{
+		TaskID:     task.ID,
+		FromStatus: task.Status,
+		ToStatus:   to,
+		ChangedBy:  userID,
+		EnteredAt:  time.Now(),
+	}
+
+	// Updating through an empty model keeps the task as loaded until the
+	// update is known to have worked
+	result := tx.Model(&models.Task{}).Where("id = ? AND version = ?", task.ID, task.Version).Updates(map[string]interface{}{
+		"status":            to,
+		"status_changed_at": change.EnteredAt,
+		"version":           task.Version + 1,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+
+	task.Status = to
+	task.StatusChangedAt = change.EnteredAt
+	task.Version++
+
+	if err := tx.Create(&change).Error; err != nil {
+		return err
+	}
+
+	if err := touchParents(tx, *task); err != nil {
+		return err
+	}
+
+	if err := recordChanges(tx, before, *task, userID); err != nil {
+		return err
+	}
+
+	if to == models.StatusCompleted {
+		if err := scheduleNextOccurrence(tx, task, userID); err != nil {
+			return err
+		}
+	}
+
+	if !task.Open() {
+		return completeParent(tx, task, userID)
+	}
+
+	return nil
+}
+
+func TransitionTask(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	task, ok := findTask(c, c.Param("id"), accessWrite)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Status models.TaskStatus `json:"status" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil || !body.Status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Status is empty or invalid",
+			"statuses": models.TaskStatuses,
+		})
+		return
+	}
+
+	if !checkIfMatch(c, task) {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return changeStatus(tx, &task, body.Status, userID)
+	})
+
+	if err == errVersionConflict {
+		respondVersionConflict(c, task)
+		return
+	}
+
+	if transition, ok := err.(*transitionError); ok {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   transition.Error(),
+			"allowed": transition.Allowed,
+		})
+		return
+	}
+
+	if blocked, ok := err.(*blockedError); ok {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    blocked.Error(),
+			"blockers": blocked.Blockers,
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to change task status",
+		})
+		return
+	}
+
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task status changed successfully",
+		"task":    task,
+	})
+}
+
+func GetTaskTransitions(c *gin.Context) {
+	task, ok := findTask(c, c.Param("id"), accessRead)
+	if !ok {
+		return
+	}
+
+	var changes []models.TaskStatusChange
+	err := config.DB.Where("task_id = ?", task.ID).Order("entered_at").Find(&changes).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find status changes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
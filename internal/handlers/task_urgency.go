@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"task-manager/internal/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// priorityPoints is what each priority adds to the urgency of a task.
+var priorityPoints = map[models.TaskPriority]int{
+	models.PriorityLow:    0,
+	models.PriorityNormal: 10,
+	models.PriorityHigh:   25,
+	models.PriorityUrgent: 40,
+}
+
+const (
+	overduePoints = 50
+	// Due points grow linearly from zero dueHorizon ahead of the due time
+	duePoints  = 40
+	dueHorizon = 14 * 24 * time.Hour
+	// Age points grow linearly until the task is ageHorizon old
+	agePoints  = 10
+	ageHorizon = 30 * 24 * time.Hour
+)
+
+// urgencyExpr is the urgency of a task as of the given time. Urgency adds up
+// points for priority, for how close the due time is and for how long the
+// task has been waiting; closed tasks always score zero. A fixed time keeps
+// the ranking stable while a client pages through it.
+func urgencyExpr(asOf time.Time) clause.Expr {
+	var priority strings.Builder
+	for _, p := range models.TaskPriorities {
+		fmt.Fprintf(&priority, " WHEN '%s' THEN %d", p, priorityPoints[p])
+	}
+
+	sql := fmt.Sprintf(`(CASE WHEN tasks.status IN ? THEN 0 ELSE
+		(CASE tasks.priority%s ELSE 0 END)
+		+ (CASE WHEN tasks.due_at IS NULL THEN 0
+			WHEN tasks.due_at <= ? THEN %d
+			ELSE %d * GREATEST(0, 1 - EXTRACT(EPOCH FROM (tasks.due_at - ?)) / %d) END)
+		+ %d * LEAST(1, GREATEST(0, EXTRACT(EPOCH FROM (? - tasks.created_at)) / %d))
+	END)::double precision`,
+		priority.String(),
+		overduePoints,
+		duePoints, int(dueHorizon.Seconds()),
+		agePoints, int(ageHorizon.Seconds()),
+	)
+
+	return clause.Expr{
+		SQL:  sql,
+		Vars: []interface{}{models.ClosedTaskStatuses, asOf, asOf, asOf},
+	}
+}
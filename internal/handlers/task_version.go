@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errVersionConflict means the task changed since it was loaded.
+var errVersionConflict = errors.New("Task was changed by someone else")
+
+// taskETag is the entity tag of a task. Besides its version it holds its
+// urgency, which is worked out when the task is loaded and changes over time
+// without the version, so a cached copy never keeps a stale urgency.
+func taskETag(task models.Task) string {
+	return fmt.Sprintf(`"%d-%s"`, task.Version, strconv.FormatFloat(task.Urgency, 'f', -1, 64))
+}
+
+// matchesETag reports whether an If-Match or If-None-Match header lists the
+// entity tag. If-Match compares strongly, so weak tags never match it.
+func matchesETag(header string, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesVersion reports whether an If-Match header lists a tag of the
+// version. The urgency in the tag is left out, as it changing overwrites
+// nothing; a bare version like "3" is accepted too.
+func matchesVersion(header string, version uint) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		tagVersion, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+		if tagVersion == strconv.FormatUint(uint64(version), 10) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch responds with 412 when the request's If-Match header doesn't
+// match the task's version, as the client would overwrite changes it hasn't
+// seen.
+func checkIfMatch(c *gin.Context, task models.Task) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchesVersion(header, task.Version) {
+		return true
+	}
+
+	respondVersionConflict(c, task)
+	return false
+}
+
+func respondVersionConflict(c *gin.Context, task models.Task) {
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   errVersionConflict.Error(),
+		"version": task.Version,
+	})
+}
+
+// notModified responds with 304 when the request's If-None-Match header
+// matches the task.
+func notModified(c *gin.Context, task models.Task) bool {
+	c.Header("ETag", taskETag(task))
+
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !matchesETag(header, taskETag(task), true) {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// saveTask saves every field of the task and bumps its version, as long as
+// nobody else saved it since it was loaded.
+func saveTask(tx *gorm.DB, task *models.Task) error {
+	version := task.Version
+	task.Version++
+
+	result := tx.Model(task).Select("*").Where("version = ?", version).Updates(task)
+	if result.Error != nil {
+		task.Version = version
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		task.Version = version
+		return errVersionConflict
+	}
+
+	return nil
+}
+
+// touchTasks bumps the version of the tasks matched by the conditions, for
+// changes that show in a task without saving its row, like its labels,
+// assignees or checklist changing.
+func touchTasks(tx *gorm.DB, query interface{}, args ...interface{}) error {
+	return tx.Unscoped().Model(&models.Task{}).
+		Where(query, args...).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
+
+// touchTask is touchTasks for a loaded task, which is kept in step.
+func touchTask(tx *gorm.DB, task *models.Task) error {
+	if err := touchTasks(tx, "id = ?", task.ID); err != nil {
+		return err
+	}
+	task.Version++
+	return nil
+}
+
+// touchParents bumps the versions of the tasks' parents, whose subtask counts
+// change when a subtask is created, deleted, moved or changes status.
+func touchParents(tx *gorm.DB, tasks ...models.Task) error {
+	var parents []uint
+	for _, task := range tasks {
+		if task.ParentID != nil {
+			parents = append(parents, *task.ParentID)
+		}
+	}
+	if len(parents) == 0 {
+		return nil
+	}
+	return touchTasks(tx, "id IN ?", parents)
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{name: "Same Tag", header: `"3"`, want: true},
+		{name: "Other Tag", header: `"2"`, want: false},
+		{name: "Any", header: `*`, want: true},
+		{name: "List", header: `"1", "3"`, want: true},
+		{name: "Weak Tag Strong Comparison", header: `W/"3"`, want: false},
+		{name: "Weak Tag Weak Comparison", header: `W/"3"`, weak: true, want: true},
+		{name: "Unquoted", header: `3`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchesETag(tt.header, `"3"`, tt.weak))
+		})
+	}
+}
+
+func TestMatchesVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "Same Version", header: `"3-12.5"`, want: true},
+		{name: "Bare Version", header: `"3"`, want: true},
+		{name: "Other Version", header: `"2-12.5"`, want: false},
+		{name: "Any", header: `*`, want: true},
+		{name: "List", header: `"1-0", "3-0"`, want: true},
+		{name: "Weak Tag", header: `W/"3-0"`, want: false},
+		{name: "Unquoted", header: `3`, want: false},
+		{name: "Version Prefix", header: `"33-0"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchesVersion(tt.header, 3))
+		})
+	}
+}
+
+func TestTaskPreconditions(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	// A dry run loads tasks at version 0 created by user 0, so the caller owns them
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(0))
+	})
+
+	tasks := router.Group("/v1/tasks")
+	tasks.GET("/:id", GetTask)
+	tasks.PATCH("/:id", PatchTask)
+	tasks.DELETE("/:id", DeleteTask)
+	tasks.POST("/:id/transition", TransitionTask)
+
+	required := router.Group("/required", middlewares.RequireIfMatch(true))
+	required.DELETE("/:id", DeleteTask)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		header         string
+		value          string
+		body           string
+		expectedStatus int
+		expectedETag   string
+	}{
+		{name: "Get", method: "GET", url: "/v1/tasks/42", expectedStatus: http.StatusOK, expectedETag: `"0-0"`},
+		{name: "Get Not Modified", method: "GET", url: "/v1/tasks/42", header: "If-None-Match", value: `"0-0"`, expectedStatus: http.StatusNotModified, expectedETag: `"0-0"`},
+		{name: "Get Changed", method: "GET", url: "/v1/tasks/42", header: "If-None-Match", value: `"7-0"`, expectedStatus: http.StatusOK, expectedETag: `"0-0"`},
+		{name: "Get Urgency Changed", method: "GET", url: "/v1/tasks/42", header: "If-None-Match", value: `"0-12.5"`, expectedStatus: http.StatusOK, expectedETag: `"0-0"`},
+		{name: "Patch Without If-Match", method: "PATCH", url: "/v1/tasks/42", body: `{"title":"Renamed"}`, expectedStatus: http.StatusOK, expectedETag: `"1-0"`},
+		{name: "Patch Matching", method: "PATCH", url: "/v1/tasks/42", header: "If-Match", value: `"0-0"`, body: `{"title":"Renamed"}`, expectedStatus: http.StatusOK, expectedETag: `"1-0"`},
+		{name: "Patch Matching Older Urgency", method: "PATCH", url: "/v1/tasks/42", header: "If-Match", value: `"0-12.5"`, body: `{"title":"Renamed"}`, expectedStatus: http.StatusOK, expectedETag: `"1-0"`},
+		{name: "Patch Stale", method: "PATCH", url: "/v1/tasks/42", header: "If-Match", value: `"5"`, body: `{"title":"Renamed"}`, expectedStatus: http.StatusPreconditionFailed, expectedETag: `"0-0"`},
+		{name: "Transition Stale", method: "POST", url: "/v1/tasks/42/transition", header: "If-Match", value: `"5"`, body: `{"status":"ongoing"}`, expectedStatus: http.StatusPreconditionFailed, expectedETag: `"0-0"`},
+		{name: "Delete Stale", method: "DELETE", url: "/v1/tasks/42", header: "If-Match", value: `"5"`, expectedStatus: http.StatusPreconditionFailed, expectedETag: `"0-0"`},
+		{name: "Delete Matching", method: "DELETE", url: "/v1/tasks/42", header: "If-Match", value: `*`, expectedStatus: http.StatusNoContent},
+		{name: "Delete Requires If-Match", method: "DELETE", url: "/required/42", expectedStatus: http.StatusPreconditionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Equal(t, tt.expectedETag, recorder.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestSaveTaskChecksVersion(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(0))
+	})
+	router.PATCH("/v1/tasks/:id", PatchTask)
+
+	req, err := createJSONRequest("PATCH", "/v1/tasks/42", map[string]interface{}{"title": "Renamed"})
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	found := false
+	for _, statement := range *statements {
+		if strings.HasPrefix(statement, "UPDATE tasks SET ") {
+			found = true
+			assert.Contains(t, statement, "version=1")
+			assert.Contains(t, statement, "WHERE version = 0 AND")
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestConcurrentChanges(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		raced          bool
+		expectedStatus int
+		expectedETag   string
+	}{
+		{name: "Patch", method: "PATCH", url: "/v1/tasks/42", body: `{"title":"Renamed"}`, expectedStatus: http.StatusOK, expectedETag: `"1-0"`},
+		{name: "Patch Raced", method: "PATCH", url: "/v1/tasks/42", body: `{"title":"Renamed"}`, raced: true, expectedStatus: http.StatusPreconditionFailed, expectedETag: `"0-0"`},
+		{name: "Transition", method: "POST", url: "/v1/tasks/42/transition", body: `{"status":"ongoing"}`, expectedStatus: http.StatusOK, expectedETag: `"1-0"`},
+		{name: "Transition Raced", method: "POST", url: "/v1/tasks/42/transition", body: `{"status":"ongoing"}`, raced: true, expectedStatus: http.StatusPreconditionFailed, expectedETag: `"0-0"`},
+		{name: "Delete", method: "DELETE", url: "/v1/tasks/42", expectedStatus: http.StatusNoContent},
+		{name: "Delete Raced", method: "DELETE", url: "/v1/tasks/42", raced: true, expectedStatus: http.StatusPreconditionFailed, expectedETag: `"0-0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			config.DB.Callback().Query().After("test:primary_key").Register("test:status", func(tx *gorm.DB) {
+				if task, ok := tx.Statement.Dest.(*models.Task); ok {
+					task.Status = models.StatusNew
+				}
+			})
+
+			// Someone else saved the task between loading and saving it
+			if tt.raced {
+				matchNoRows("version = 0")
+			}
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(0))
+			})
+			router.PATCH("/v1/tasks/:id", PatchTask)
+			router.DELETE("/v1/tasks/:id", DeleteTask)
+			router.POST("/v1/tasks/:id/transition", TransitionTask)
+
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Equal(t, tt.expectedETag, recorder.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestChangesShownByTaskBumpVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		route         string
+		handler       gin.HandlerFunc
+		url           string
+		body          map[string]interface{}
+		shouldContain string
+	}{
+		{
+			name:          "Attach Label",
+			method:        "POST",
+			route:         "/v1/tasks/:id/labels/:label_id",
+			handler:       AttachLabel,
+			url:           "/v1/tasks/1/labels/2",
+			shouldContain: "UPDATE tasks SET version=version + 1 WHERE id = 1",
+		},
+		{
+			name:          "Detach Label",
+			method:        "DELETE",
+			route:         "/v1/tasks/:id/labels/:label_id",
+			handler:       DetachLabel,
+			url:           "/v1/tasks/1/labels/2",
+			shouldContain: "UPDATE tasks SET version=version + 1 WHERE id = 1",
+		},
+		{
+			name:          "Update Label",
+			method:        "PUT",
+			route:         "/v1/labels/:id",
+			handler:       UpdateLabel,
+			url:           "/v1/labels/1",
+			body:          map[string]interface{}{"name": "bug", "color": "#1f6feb"},
+			shouldContain: "UPDATE tasks SET version=version + 1 WHERE id IN (SELECT task_id FROM task_labels WHERE label_id = 1)",
+		},
+		{
+			name:          "Add Checklist Item",
+			method:        "POST",
+			route:         "/v1/tasks/:id/checklist",
+			handler:       CreateChecklistItem,
+			url:           "/v1/tasks/1/checklist",
+			body:          map[string]interface{}{"text": "Write tests"},
+			shouldContain: "UPDATE tasks SET version=version + 1 WHERE id = 1",
+		},
+		{
+			name:          "Restore",
+			method:        "POST",
+			route:         "/v1/tasks/:id/restore",
+			handler:       RestoreTask,
+			url:           "/v1/tasks/1/restore",
+			shouldContain: "UPDATE tasks SET deleted_at=NULL,version=version + 1",
+		},
+		{
+			name:          "Delete Project",
+			method:        "DELETE",
+			route:         "/v1/projects/:id",
+			handler:       DeleteProject,
+			url:           "/v1/projects/1",
+			shouldContain: "UPDATE tasks SET project_id=NULL,version=version + 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			// A dry run loads records owned by user 0
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(0))
+			})
+			router.Handle(tt.method, tt.route, tt.handler)
+
+			req, err := createJSONRequest(tt.method, tt.url, tt.body)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			assert.Contains(t, strings.Join(*statements, "\n"), tt.shouldContain)
+		})
+	}
+}
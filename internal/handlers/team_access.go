@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"task-manager/config"
+	"task-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// memberTeams is a subquery for the teams the user belongs to with at least the given role.
+func memberTeams(db *gorm.DB, userID uint, role models.TeamRole) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Membership{}).
+		Select("team_id").
+		Where("user_id = ? AND role IN ?", userID, models.RolesAtLeast(role))
+}
+
+// teamRole returns the user's role in a team, or an empty role for outsiders.
+func teamRole(userID uint, teamID uint) models.TeamRole {
+	var membership models.Membership
+	err := config.DB.First(&membership, "team_id = ? AND user_id = ?", teamID, userID).Error
+	if err != nil {
+		return ""
+	}
+	return membership.Role
+}
+
+// projectRole treats the owner of a personal project like a team owner.
+func projectRole(userID uint, project models.Project) models.TeamRole {
+	if project.TeamID != nil {
+		return teamRole(userID, *project.TeamID)
+	}
+	if project.OwnerID == userID {
+		return models.RoleOwner
+	}
+	return ""
+}
+
+// taskTeamRole is the user's role in the team of the task's project, if any.
+func taskTeamRole(task models.Task, userID uint) models.TeamRole {
+	if task.ProjectID == nil {
+		return ""
+	}
+
+	var project models.Project
+	err := config.DB.First(&project, *task.ProjectID).Error
+	if err != nil || project.TeamID == nil {
+		return ""
+	}
+	return teamRole(userID, *project.TeamID)
+}
+
+func contextTeamRole(c *gin.Context) models.TeamRole {
+	role, _ := c.Get("team_role")
+	teamRole, _ := role.(models.TeamRole)
+	return teamRole
+}
+
+// canGrantRole reports whether the user can invite someone with the role or
+// give it to a member: only roles below their own, so ownership is only ever
+// handed over by a transfer.
+func canGrantRole(c *gin.Context, role models.TeamRole) bool {
+	return role.Valid() && !role.AtLeast(contextTeamRole(c))
+}
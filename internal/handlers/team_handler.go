@@ -0,0 +1,540 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/mailer"
+	"task-manager/internal/models"
+	"task-manager/internal/tokens"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type teamMember struct {
+	UserID   uint            `json:"user_id"`
+	Username string          `json:"username"`
+	Email    string          `json:"email"`
+	Role     models.TeamRole `json:"role"`
+}
+
+func CreateTeam(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	// The creator becomes the owner
+	newTeam := models.Team{Name: body.Name}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newTeam).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{TeamID: newTeam.ID, UserID: userID, Role: models.RoleOwner}).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error creating team",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team created successfully",
+		"team":    newTeam,
+	})
+}
+
+func GetTeams(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var teams []struct {
+		ID        uint            `json:"id"`
+		Name      string          `json:"name"`
+		Role      models.TeamRole `json:"role"`
+		CreatedAt time.Time       `json:"created_at"`
+	}
+	err := config.DB.Model(&models.Team{}).
+		Select("teams.id, teams.name, memberships.role, teams.created_at").
+		Joins("JOIN memberships ON memberships.team_id = teams.id AND memberships.deleted_at IS NULL").
+		Where("memberships.user_id = ?", userID).
+		Order("teams.name").
+		Scan(&teams).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find teams",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
+
+func GetTeam(c *gin.Context) {
+	var team models.Team
+	err := config.DB.First(&team, c.GetUint("team_id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Team not found",
+		})
+		return
+	}
+
+	var members []teamMember
+	err = config.DB.Model(&models.Membership{}).
+		Select("memberships.user_id, users.username, users.email, memberships.role").
+		Joins("JOIN users ON users.id = memberships.user_id AND users.deleted_at IS NULL").
+		Where("memberships.team_id = ?", team.ID).
+		Order("users.username").
+		Scan(&members).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find team members",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team":    team,
+		"members": members,
+		"role":    contextTeamRole(c),
+	})
+}
+
+func UpdateTeam(c *gin.Context) {
+	var body struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	err := config.DB.Model(&models.Team{}).Where("id = ?", c.GetUint("team_id")).Update("name", body.Name).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update team",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team updated successfully",
+	})
+}
+
+func DeleteTeam(c *gin.Context) {
+	teamID := c.GetUint("team_id")
+
+	// Team projects and labels stay with the members who created them
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Project{}).Where("team_id = ?", teamID).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		err := touchTasks(tx, "id IN (SELECT task_labels.task_id FROM task_labels JOIN labels ON labels.id = task_labels.label_id WHERE labels.team_id = ?)", teamID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Label{}).Where("team_id = ?", teamID).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", teamID).Delete(&models.TeamInvitation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("team_id = ?", teamID).Delete(&models.Membership{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Team{}, teamID).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error deleting team",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team deleted successfully",
+	})
+}
+
+// invitationMessage is the email carrying an invitation token.
+func invitationMessage(invitation models.TeamInvitation, team models.Team, secret string) mailer.Message {
+	return mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("Join %s", team.Name),
+		Body: fmt.Sprintf("Hi,\n\n"+
+			"you were invited to join the team %s as %s. Sign in with this email address and accept the invitation with this token:\n\n"+
+			"%s\n\n"+
+			"It expires at %s. If you weren't expecting it, you can ignore this email.\n",
+			team.Name, invitation.Role, secret, invitation.ExpiresAt.UTC().Format(time.RFC1123)),
+	}
+}
+
+func InviteMember(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		Email string          `json:"email" binding:"required"`
+		Role  models.TeamRole `json:"role"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	if body.Role == "" {
+		body.Role = models.RoleMember
+	}
+
+	if !canGrantRole(c, body.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid role, members can only be given a role below your own",
+		})
+		return
+	}
+
+	var team models.Team
+	if err := config.DB.First(&team, c.GetUint("team_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+
+	token, err := tokens.Generate("inv_")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating invitation"})
+		return
+	}
+
+	invitation := models.TeamInvitation{
+		TeamID:    team.ID,
+		Email:     strings.ToLower(strings.TrimSpace(body.Email)),
+		Role:      body.Role,
+		TokenHash: tokens.Hash(token),
+		InvitedBy: userID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+
+	result := config.DB.Create(&invitation)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error creating invitation",
+		})
+		return
+	}
+
+	// The token only goes to the invitee, who accepts it with POST /team/invitations/accept
+	if err := config.Mailer.Send(invitationMessage(invitation, team, token)); err != nil {
+		if err := config.DB.Unscoped().Where("token_hash = ?", invitation.TokenHash).Delete(&models.TeamInvitation{}).Error; err != nil {
+			log.Println("❌ Failed to delete unsent invitation:", err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error sending invitation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Invitation sent successfully",
+		"invitation": invitation,
+	})
+}
+
+func GetInvitations(c *gin.Context) {
+	var invitations []models.TeamInvitation
+	err := config.DB.
+		Where("team_id = ? AND accepted_at IS NULL AND expires_at > ?", c.GetUint("team_id"), time.Now()).
+		Order("created_at").
+		Find(&invitations).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find invitations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func RevokeInvitation(c *gin.Context) {
+	result := config.DB.
+		Where("id = ? AND team_id = ? AND accepted_at IS NULL", c.Param("invitation_id"), c.GetUint("team_id")).
+		Delete(&models.TeamInvitation{})
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error revoking invitation",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Invitation not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation revoked successfully",
+	})
+}
+
+func AcceptInvitation(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var invitation models.TeamInvitation
+	err := config.DB.First(&invitation, "token_hash = ? AND accepted_at IS NULL AND expires_at > ?", tokens.Hash(body.Token), time.Now()).Error
+
+	// Invitations are bound to the address they were sent to
+	if err != nil || !strings.EqualFold(invitation.Email, strings.TrimSpace(user.Email)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Invitation not found or expired",
+		})
+		return
+	}
+
+	if teamRole(userID, invitation.TeamID) != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You are already a member of this team",
+		})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		accepted := tx.Model(&invitation).Where("accepted_at IS NULL").Update("accepted_at", time.Now())
+		if accepted.Error != nil {
+			return accepted.Error
+		}
+		if accepted.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(&models.Membership{TeamID: invitation.TeamID, UserID: userID, Role: invitation.Role}).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error accepting invitation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Joined team successfully",
+		"team_id": invitation.TeamID,
+		"role":    invitation.Role,
+	})
+}
+
+// findMember loads a membership the current member outranks, since members can
+// only manage people below their own role.
+func findMember(c *gin.Context) (models.Membership, bool) {
+	var membership models.Membership
+	err := config.DB.First(&membership, "team_id = ? AND user_id = ?", c.GetUint("team_id"), c.Param("user_id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Member not found",
+		})
+		return membership, false
+	}
+
+	if membership.Role.AtLeast(contextTeamRole(c)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You can only manage members below your own role",
+		})
+		return membership, false
+	}
+
+	return membership, true
+}
+
+func UpdateMemberRole(c *gin.Context) {
+	membership, ok := findMember(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Role models.TeamRole `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	if !canGrantRole(c, body.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid role, members can only be given a role below your own",
+		})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Membership{}).
+			Where("team_id = ? AND user_id = ?", membership.TeamID, membership.UserID).
+			Update("role", body.Role).Error
+		if err != nil || body.Role.AtLeast(models.RoleMember) {
+			return err
+		}
+
+		// Viewers can't work on tasks, so they can't stay assigned
+		return unassignFromTeam(tx, membership.TeamID, membership.UserID)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update member",
+		})
+		return
+	}
+
+	membership.Role = body.Role
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Member updated successfully",
+		"membership": membership,
+	})
+}
+
+func RemoveMember(c *gin.Context) {
+	membership, ok := findMember(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Where("team_id = ? AND user_id = ?", membership.TeamID, membership.UserID).
+			Delete(&models.Membership{}).Error
+		if err != nil {
+			return err
+		}
+		return unassignFromTeam(tx, membership.TeamID, membership.UserID)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error removing member",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed successfully",
+	})
+}
+
+func LeaveTeam(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	if contextTeamRole(c) == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Transfer ownership before leaving the team",
+		})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("team_id = ? AND user_id = ?", c.GetUint("team_id"), userID).Delete(&models.Membership{}).Error
+		if err != nil {
+			return err
+		}
+		return unassignFromTeam(tx, c.GetUint("team_id"), userID)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error leaving team",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Left team successfully",
+	})
+}
+
+func TransferTeamOwnership(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	teamID := c.GetUint("team_id")
+
+	var body struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil || body.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	// The previous owner stays on as an admin
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		promoted := tx.Model(&models.Membership{}).
+			Where("team_id = ? AND user_id = ?", teamID, body.UserID).
+			Update("role", models.RoleOwner)
+		if promoted.Error != nil {
+			return promoted.Error
+		}
+		if promoted.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Model(&models.Membership{}).
+			Where("team_id = ? AND user_id = ?", teamID, userID).
+			Update("role", models.RoleAdmin).Error
+	})
+
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Member not found",
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to transfer ownership",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ownership transferred successfully",
+	})
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTeamRoleMiddlewareRejectsOutsiders(t *testing.T) {
+	tests := []struct {
+		name           string
+		membership     *models.Membership
+		expectedStatus int
+	}{
+		{name: "Not A Member", expectedStatus: http.StatusNotFound},
+		{name: "Role Too Low", membership: &models.Membership{TeamID: 3, UserID: 7, Role: models.RoleViewer}, expectedStatus: http.StatusForbidden},
+		{name: "Member", membership: &models.Membership{TeamID: 3, UserID: 7, Role: models.RoleAdmin}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			config.DB.Callback().Query().After("test:primary_key").Register("test:membership", func(tx *gorm.DB) {
+				membership, ok := tx.Statement.Dest.(*models.Membership)
+				if !ok {
+					return
+				}
+				if tt.membership == nil {
+					tx.AddError(gorm.ErrRecordNotFound)
+					return
+				}
+				*membership = *tt.membership
+			})
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+			})
+			router.GET("/team/:id", middlewares.TeamRoleMiddleware(models.RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, err := createJSONRequest("GET", "/team/3", nil)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestTeamRolesCanOnlyBeGrantedDownwards(t *testing.T) {
+	_, err := setupDryRunDB()
+	assert.NoError(t, err)
+	config.Mailer = &recordingMailer{}
+
+	tests := []struct {
+		name           string
+		actor          models.TeamRole
+		url            string
+		method         string
+		requestBody    map[string]interface{}
+		expectedStatus int
+	}{
+		{name: "Admin Invites Member", actor: models.RoleAdmin, method: "POST", url: "/team/3/invitations", requestBody: map[string]interface{}{"email": "a@example.com", "role": "member"}, expectedStatus: http.StatusOK},
+		{name: "Admin Invites Admin", actor: models.RoleAdmin, method: "POST", url: "/team/3/invitations", requestBody: map[string]interface{}{"email": "a@example.com", "role": "admin"}, expectedStatus: http.StatusBadRequest},
+		{name: "Owner Invites Admin", actor: models.RoleOwner, method: "POST", url: "/team/3/invitations", requestBody: map[string]interface{}{"email": "a@example.com", "role": "admin"}, expectedStatus: http.StatusOK},
+		{name: "Admin Invites Owner", actor: models.RoleAdmin, method: "POST", url: "/team/3/invitations", requestBody: map[string]interface{}{"email": "a@example.com", "role": "owner"}, expectedStatus: http.StatusBadRequest},
+		{name: "Owner Invites Owner", actor: models.RoleOwner, method: "POST", url: "/team/3/invitations", requestBody: map[string]interface{}{"email": "a@example.com", "role": "owner"}, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown Role", actor: models.RoleOwner, method: "POST", url: "/team/3/invitations", requestBody: map[string]interface{}{"email": "a@example.com", "role": "root"}, expectedStatus: http.StatusBadRequest},
+		{name: "Admin Promotes To Member", actor: models.RoleAdmin, method: "PUT", url: "/team/3/members/8", requestBody: map[string]interface{}{"role": "member"}, expectedStatus: http.StatusOK},
+		{name: "Owner Promotes To Admin", actor: models.RoleOwner, method: "PUT", url: "/team/3/members/8", requestBody: map[string]interface{}{"role": "admin"}, expectedStatus: http.StatusOK},
+		{name: "Owner Promotes To Owner", actor: models.RoleOwner, method: "PUT", url: "/team/3/members/8", requestBody: map[string]interface{}{"role": "owner"}, expectedStatus: http.StatusBadRequest},
+		{name: "Admin Promotes To Admin", actor: models.RoleAdmin, method: "PUT", url: "/team/3/members/8", requestBody: map[string]interface{}{"role": "admin"}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+				c.Set("team_id", uint(3))
+				c.Set("team_role", tt.actor)
+			})
+			router.POST("/team/:id/invitations", InviteMember)
+			router.PUT("/team/:id/members/:user_id", UpdateMemberRole)
+
+			req, err := createJSONRequest(tt.method, tt.url, tt.requestBody)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestInviteMemberEmailsTheToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		sendErr        error
+		expectedStatus int
+	}{
+		{name: "Sent", expectedStatus: http.StatusOK},
+		{name: "Sending Fails", sendErr: errors.New("connection refused"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			onQuery("FROM teams WHERE", func(tx *gorm.DB) {
+				if team, ok := tx.Statement.Dest.(*models.Team); ok {
+					team.Name = "Platform"
+				}
+			})
+
+			mail := &recordingMailer{err: tt.sendErr}
+			config.Mailer = mail
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+				c.Set("team_id", uint(3))
+				c.Set("team_role", models.RoleAdmin)
+			})
+			router.POST("/team/:id/invitations", InviteMember)
+
+			req, err := createJSONRequest("POST", "/team/3/invitations", map[string]interface{}{"email": " Jane@Example.com ", "role": "member"})
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			// Only the invitee gets the token
+			assert.NotContains(t, recorder.Body.String(), "inv_")
+			assert.Len(t, mail.sent, 1)
+			assert.Equal(t, "jane@example.com", mail.sent[0].To)
+			assert.Contains(t, mail.sent[0].Subject, "Platform")
+			assert.Contains(t, mail.sent[0].Body, "inv_")
+
+			all := strings.Join(*statements, "\n")
+			assert.Contains(t, all, "INSERT INTO team_invitations")
+			if tt.sendErr != nil {
+				// Nobody got the token, so the invitation goes away
+				assert.Contains(t, all, "DELETE FROM team_invitations WHERE token_hash = ")
+			} else {
+				assert.NotContains(t, all, "DELETE FROM team_invitations")
+			}
+		})
+	}
+}
+
+func TestDemotingToViewerUnassignsFromTeamTasks(t *testing.T) {
+	tests := []struct {
+		name          string
+		role          string
+		shouldContain []string
+		notContain    []string
+	}{
+		{
+			name:          "Demoted To Viewer",
+			role:          "viewer",
+			shouldContain: []string{"UPDATE memberships SET role='viewer'", "DELETE FROM task_assignees WHERE user_id = 8 AND task_id IN (SELECT tasks.id FROM tasks JOIN projects ON projects.id = tasks.project_id WHERE projects.team_id = 3"},
+		},
+		{
+			name:          "Made Member",
+			role:          "member",
+			shouldContain: []string{"UPDATE memberships SET role='member'"},
+			notContain:    []string{"DELETE FROM task_assignees"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			config.DB.Callback().Query().After("test:primary_key").Register("test:membership", func(tx *gorm.DB) {
+				if membership, ok := tx.Statement.Dest.(*models.Membership); ok {
+					*membership = models.Membership{TeamID: 3, UserID: 8, Role: models.RoleMember}
+				}
+			})
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+				c.Set("team_id", uint(3))
+				c.Set("team_role", models.RoleAdmin)
+			})
+			router.PUT("/team/:id/members/:user_id", UpdateMemberRole)
+
+			req, err := createJSONRequest("PUT", "/team/3/members/8", map[string]interface{}{"role": tt.role})
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			for _, expected := range tt.shouldContain {
+				assert.Contains(t, all, expected)
+			}
+			for _, unexpected := range tt.notContain {
+				assert.NotContains(t, all, unexpected)
+			}
+		})
+	}
+}
+
+func TestTeamTasksAreVisibleToMembers(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	})
+	router.GET("/task/", GetTasks)
+
+	req, err := createJSONRequest("GET", "/task/", nil)
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	found := false
+	for _, statement := range *statements {
+		if strings.Contains(statement, " FROM tasks WHERE ") {
+			found = true
+			assert.Contains(t, statement, "tasks.project_id IN (SELECT id FROM projects WHERE team_id IN (SELECT team_id FROM memberships WHERE (user_id = 7 AND role IN ('viewer','member','admin','owner'))")
+		}
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/tokens"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateToken(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var body struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Name) == "" || len(body.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fields are empty or invalid"})
+		return
+	}
+
+	for _, scope := range body.Scopes {
+		if !models.ValidTokenScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":  "Invalid scope " + scope,
+				"scopes": models.TokenScopes,
+			})
+			return
+		}
+	}
+
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		return
+	}
+
+	secret, err := tokens.Generate(tokens.PersonalAccessPrefix)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
+
+	token := models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(body.Name),
+		Prefix:    secret[:len(tokens.PersonalAccessPrefix)+8],
+		TokenHash: tokens.Hash(secret),
+		Scopes:    body.Scopes,
+		ExpiresAt: body.ExpiresAt,
+	}
+
+	if err := config.DB.Create(&token).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error creating token"})
+		return
+	}
+
+	// The token is only ever shown here
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Token created successfully",
+		"token":                 secret,
+		"personal_access_token": token,
+	})
+}
+
+func GetTokens(c *gin.Context) {
+	var accessTokens []models.PersonalAccessToken
+	err := config.DB.Where("user_id = ?", c.GetUint("user_id")).Order("id").Find(&accessTokens).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can't find tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, accessTokens)
+}
+
+func DeleteToken(c *gin.Context) {
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).
+		Delete(&models.PersonalAccessToken{})
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error deleting token"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token deleted successfully",
+	})
+}
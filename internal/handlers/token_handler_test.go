@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		expectedStatus int
+		shouldContain  string
+	}{
+		{
+			name:           "Valid",
+			requestBody:    map[string]interface{}{"name": "CI bot", "scopes": []string{"tasks:read", "tasks:write"}},
+			expectedStatus: http.StatusOK,
+			shouldContain:  `"token":"pat_`,
+		},
+		{
+			name:           "With Expiry",
+			requestBody:    map[string]interface{}{"name": "CI bot", "scopes": []string{"tasks:read"}, "expires_at": time.Now().Add(time.Hour)},
+			expectedStatus: http.StatusOK,
+			shouldContain:  `"token":"pat_`,
+		},
+		{
+			name:           "Unknown Scope",
+			requestBody:    map[string]interface{}{"name": "CI bot", "scopes": []string{"admin"}},
+			expectedStatus: http.StatusBadRequest,
+			shouldContain:  "Invalid scope admin",
+		},
+		{
+			name:           "No Scopes",
+			requestBody:    map[string]interface{}{"name": "CI bot", "scopes": []string{}},
+			expectedStatus: http.StatusBadRequest,
+			shouldContain:  "Fields are empty or invalid",
+		},
+		{
+			name:           "Blank Name",
+			requestBody:    map[string]interface{}{"name": " ", "scopes": []string{"tasks:read"}},
+			expectedStatus: http.StatusBadRequest,
+			shouldContain:  "Fields are empty or invalid",
+		},
+		{
+			name:           "Expired",
+			requestBody:    map[string]interface{}{"name": "CI bot", "scopes": []string{"tasks:read"}, "expires_at": time.Now().Add(-time.Hour)},
+			expectedStatus: http.StatusBadRequest,
+			shouldContain:  "Expiry must be in the future",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+			})
+			router.POST("/user/tokens", CreateToken)
+
+			req, err := createJSONRequest("POST", "/user/tokens", tt.requestBody)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tt.shouldContain)
+
+			if tt.expectedStatus == http.StatusOK {
+				// Only the hash of the token is stored
+				all := strings.Join(*statements, "\n")
+				assert.Contains(t, all, "INSERT INTO personal_access_tokens (user_id,name,prefix,token_hash,scopes,expires_at,last_used_at,created_at) VALUES (7,'CI bot','pat_")
+				assert.NotContains(t, all, strings.Split(strings.Split(recorder.Body.String(), `"token":"`)[1], `"`)[0])
+				assert.NotContains(t, recorder.Body.String(), "token_hash")
+			}
+		})
+	}
+}
+
+func TestDeleteTokenOnlyDeletesOwnTokens(t *testing.T) {
+	tests := []struct {
+		name           string
+		owned          bool
+		expectedStatus int
+	}{
+		{name: "Own Token", owned: true, expectedStatus: http.StatusOK},
+		{name: "Someone Else's Token", owned: false, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			if !tt.owned {
+				matchNoRows("DELETE FROM personal_access_tokens")
+			}
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+			})
+			router.DELETE("/user/tokens/:id", DeleteToken)
+
+			req, err := createJSONRequest("DELETE", "/user/tokens/3", nil)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Contains(t, strings.Join(*statements, "\n"), "DELETE FROM personal_access_tokens WHERE id = '3' AND user_id = 7")
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/internal/trash"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetTrash(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var tasks []models.Task
+	err := config.DB.Unscoped().Scopes(visibleTasks(userID)).
+		Where("tasks.deleted_at IS NOT NULL").
+		Order("tasks.deleted_at DESC, tasks.id DESC").
+		Limit(maxTaskLimit).
+		Find(&tasks).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Can't find tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+func RestoreTask(c *gin.Context) {
+	task, ok := findTrashedTask(c, c.Param("id"), accessOwner)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&task).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := touchParents(tx, task); err != nil {
+			return err
+		}
+		return recordHistory(tx, task, models.HistoryRestored, c.GetUint("user_id"))
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error restoring task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task restored successfully",
+	})
+}
+
+// PurgeTask deletes a task from the trash for good, together with its
+// comments, checklist, attachments and links to other tasks.
+func PurgeTask(c *gin.Context) {
+	task, ok := findTrashedTask(c, c.Param("id"), accessOwner)
+	if !ok {
+		return
+	}
+
+	var keys []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		keys, err = trash.Purge(tx, []uint{task.ID}, c.GetUint("user_id"))
+		return err
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Error purging task",
+		})
+		return
+	}
+
+	trash.RemoveContent(c.Request.Context(), config.Storage, keys)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task purged successfully",
+	})
+}
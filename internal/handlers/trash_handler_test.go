@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// loadTrashedTask makes a dry run load task 42 of user 7 from the trash, or
+// find nothing there when missing is set.
+func loadTrashedTask(missing bool) {
+	onQuery("FROM tasks WHERE", func(tx *gorm.DB) {
+		task, ok := tx.Statement.Dest.(*models.Task)
+		if !ok {
+			return
+		}
+		if missing {
+			tx.AddError(gorm.ErrRecordNotFound)
+			return
+		}
+		task.ID = 42
+		task.CreatedBy = 7
+	})
+}
+
+func TestGetTrash(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	})
+	router.GET("/v1/trash", GetTrash)
+
+	req, err := createJSONRequest("GET", "/v1/trash", nil)
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Only deleted tasks the caller can see are listed, newest first
+	all := strings.Join(*statements, "\n")
+	assert.Contains(t, all, "tasks.created_by = 7 OR tasks.id IN (SELECT task_id FROM task_shares WHERE user_id = 7")
+	assert.Contains(t, all, "SELECT * FROM tasks WHERE tasks.deleted_at IS NOT NULL AND (")
+	assert.Contains(t, all, "ORDER BY tasks.deleted_at DESC, tasks.id DESC")
+	assert.NotContains(t, all, "tasks.deleted_at IS NULL")
+}
+
+func TestRestoreTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		missing        bool
+		expectedStatus int
+	}{
+		{name: "Owner", userID: 7, expectedStatus: http.StatusOK},
+		{name: "Not The Owner", userID: 8, expectedStatus: http.StatusForbidden},
+		{name: "Not In Trash", userID: 7, missing: true, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			loadTrashedTask(tt.missing)
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", tt.userID)
+			})
+			router.POST("/v1/tasks/:id/restore", RestoreTask)
+
+			req, err := createJSONRequest("POST", "/v1/tasks/42/restore", nil)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			if tt.expectedStatus != http.StatusOK {
+				assert.NotContains(t, all, "UPDATE tasks")
+				assert.NotContains(t, all, "INSERT INTO task_history")
+				return
+			}
+
+			assert.Contains(t, all, "UPDATE tasks SET deleted_at=NULL,version=version + 1")
+			assert.Contains(t, all, "INSERT INTO task_history (task_id,user_id,action,field,old_value,new_value,created_at) VALUES (42,7,'restored',")
+		})
+	}
+}
+
+func TestPurgeTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		missing        bool
+		expectedStatus int
+	}{
+		{name: "Owner", userID: 7, expectedStatus: http.StatusOK},
+		{name: "Not The Owner", userID: 8, expectedStatus: http.StatusForbidden},
+		{name: "Not In Trash", userID: 7, missing: true, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := setupDryRunDB()
+			assert.NoError(t, err)
+
+			loadTrashedTask(tt.missing)
+
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", tt.userID)
+			})
+			router.DELETE("/v1/tasks/:id/purge", PurgeTask)
+
+			req, err := createJSONRequest("DELETE", "/v1/tasks/42/purge", nil)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			all := strings.Join(*statements, "\n")
+			if tt.expectedStatus != http.StatusOK {
+				assert.NotContains(t, all, "DELETE FROM")
+				return
+			}
+
+			assert.Contains(t, all, "DELETE FROM comments WHERE task_id IN (42)")
+			assert.Contains(t, all, "DELETE FROM tasks WHERE id IN (42)")
+			assert.Contains(t, all, "'purged'")
+		})
+	}
+}
@@ -2,14 +2,14 @@ package handlers
 
 import (
 	"net/http"
-	"os"
 	"task-manager/config"
 	"task-manager/internal/models"
+	"task-manager/internal/tokens"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func UserRegistration(c *gin.Context) {
@@ -96,14 +96,12 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-	})
-
-	// Sign and complete token using the secret
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	// Start a session with a new family of refresh tokens
+	family, err := tokens.Generate("")
+	var pair tokenPair
+	if err == nil {
+		pair, err = issueTokens(config.DB, user.ID, family, time.Now())
+	}
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -113,13 +111,7 @@ func UserLogin(c *gin.Context) {
 	}
 
 	// Respond
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("jwt", tokenString, 3600*24*30, "", "", false, true)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Logged in successfull",
-		"token":   tokenString,
-	})
+	pair.respond(c, "Logged in successfull")
 }
 
 //	url -X POST http://localhost:8080/user/login      -H "Content-Type: application/json"      -d '{
@@ -127,21 +119,61 @@ func UserLogin(c *gin.Context) {
 //		"password": "securepassword"
 //	  }'
 func UserLogout(c *gin.Context) {
-	// Clear JWT cookie
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("jwt", "", -1, "", "", false, true)
+	// Revoke the token, which may also be used outside the cookie, and the
+	// refresh tokens of its session
+	err := config.Revocations.Revoke(c.GetString("jti"), c.GetUint("user_id"), c.GetTime("token_expires_at"))
+	if sessionID := c.GetString("session_id"); err == nil && sessionID != "" {
+		err = revokeRefreshTokens(config.DB, time.Now(), "family_id = ?", sessionID)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to log out",
+		})
+		return
+	}
+
+	// Clear JWT cookies
+	clearTokenCookies(c)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logout successfull",
 	})
 }
 
+// UserLogoutAll revokes every token the user was issued so far, on every device.
+func UserLogoutAll(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	now := time.Now()
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Update("sessions_revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return revokeRefreshTokens(tx, now, "user_id = ?", userID)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to log out",
+		})
+		return
+	}
+
+	// Clear JWT cookies
+	clearTokenCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out everywhere successfully",
+	})
+}
+
 func UserDelete(c *gin.Context) {
 	user_id := c.GetUint("user_id")
 
-	// Clear JWT cookie
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("jwt", "", -1, "", "", false, true)
+	// Clear JWT cookies
+	clearTokenCookies(c)
 
 	// Delete user
 	err := config.DB.Delete(&models.User{}, user_id).Error
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -354,3 +356,54 @@ func TestUserDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestUserLogoutRevokesTokens(t *testing.T) {
+	statements, err := setupDryRunDB()
+	assert.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Set("jti", "abc")
+		c.Set("session_id", "fam")
+		c.Set("token_expires_at", time.Now().Add(time.Hour))
+	})
+	router.PUT("/user/logout", UserLogout)
+	router.PUT("/user/logout/all", UserLogoutAll)
+
+	tests := []struct {
+		name          string
+		url           string
+		shouldContain []string
+	}{
+		{
+			name:          "Logout",
+			url:           "/user/logout",
+			shouldContain: []string{"INSERT INTO revoked_tokens (jti,user_id,expires_at,created_at) VALUES ('abc',7,", "WHERE revoked_at IS NULL AND family_id = 'fam'"},
+		},
+		{
+			name:          "Logout Everywhere",
+			url:           "/user/logout/all",
+			shouldContain: []string{"UPDATE users SET sessions_revoked_at=", "WHERE revoked_at IS NULL AND user_id = 7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*statements = nil
+
+			req, err := createJSONRequest("PUT", tt.url, nil)
+			assert.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Header().Get("Set-Cookie"), "jwt=;")
+			all := strings.Join(*statements, "\n")
+			for _, expected := range tt.shouldContain {
+				assert.Contains(t, all, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package mailer sends emails to users.
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// headerValue drops line breaks, which would start another header.
+var headerValue = strings.NewReplacer("\r", "", "\n", "")
+
+// bytes renders the message as a plain text email.
+func (m Message) bytes(from string, date time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue.Replace(m.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue.Replace(m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+type Mailer interface {
+	Send(Message) error
+}
+
+// SMTP sends messages through a mail server, authenticating when a username
+// is set.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTP) Send(msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, []string{msg.To}, msg.bytes(s.From, time.Now()))
+}
+
+// File writes each message to its own .eml file in Dir, for local
+// development.
+type File struct {
+	Dir  string
+	From string
+}
+
+func (f File) Send(msg Message) error {
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), sanitize(msg.To))
+	return os.WriteFile(filepath.Join(f.Dir, name), msg.bytes(f.From, now), 0o600)
+}
+
+// sanitize keeps an address usable in a file name.
+func sanitize(address string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, address)
+}
+
+// Log writes messages to the log instead of sending them.
+type Log struct{}
+
+func (Log) Send(msg Message) error {
+	log.Printf("✉️ Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := Message{To: "alice@example.com", Subject: "Hello", Body: "Line one\nLine two"}
+	date := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "From: tasks@example.com\r\n"+
+		"To: alice@example.com\r\n"+
+		"Subject: Hello\r\n"+
+		"Date: Wed, 01 May 2024 09:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"Line one\r\nLine two", string(msg.bytes("tasks@example.com", date)))
+}
+
+func TestMessageHeadersCantBreakLines(t *testing.T) {
+	msg := Message{To: "alice@example.com\r\nBcc: eve@example.com", Subject: "Hello"}
+	assert.Contains(t, string(msg.bytes("tasks@example.com", time.Now())), "To: alice@example.comBcc: eve@example.com\r\n")
+}
+
+func TestFileWritesMessages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := File{Dir: dir, From: "tasks@example.com"}
+
+	assert.NoError(t, mailer.Send(Message{To: "alice@example.com", Subject: "First", Body: "1"}))
+	assert.NoError(t, mailer.Send(Message{To: "../bob@example.com", Subject: "Second", Body: "2"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	var contents []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+	all := strings.Join(contents, "\n")
+	assert.Contains(t, all, "Subject: First")
+	assert.Contains(t, all, "Subject: Second")
+}
@@ -0,0 +1,25 @@
+package mentions
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// An @ only starts a mention at the beginning of the text or after a
+// character that can't be part of a word, so email addresses are skipped.
+var mention = regexp.MustCompile(`(?:^|[^\w@.])@([\w.-]+)`)
+
+// Parse returns the usernames mentioned in the text, once each, in the order
+// they first appear.
+func Parse(text string) []string {
+	var usernames []string
+	for _, match := range mention.FindAllStringSubmatch(text, -1) {
+		// Sentence punctuation right after a name isn't part of it
+		username := strings.TrimRight(match[1], ".-")
+		if username != "" && !slices.Contains(usernames, username) {
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
@@ -0,0 +1,31 @@
+package mentions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "No Mentions", text: "Looks good to me", want: nil},
+		{name: "Single Mention", text: "@alice can you review?", want: []string{"alice"}},
+		{name: "Several Mentions", text: "cc @bob, @carol_1 and @dave.smith", want: []string{"bob", "carol_1", "dave.smith"}},
+		{name: "Repeated Mention", text: "@bob @bob please", want: []string{"bob"}},
+		{name: "Trailing Punctuation", text: "Thanks @erin.", want: []string{"erin"}},
+		{name: "Email Address", text: "Mail frank@example.com", want: nil},
+		{name: "Double At", text: "@@grace", want: nil},
+		{name: "After Newline", text: "Done\n@heidi", want: []string{"heidi"}},
+		{name: "In Parentheses", text: "(@ivan)", want: []string{"ivan"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.text))
+		})
+	}
+}
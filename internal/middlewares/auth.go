@@ -1,27 +1,236 @@
 package middlewares
 
 import (
+	"crypto/hmac"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"strings"
 	"task-manager/config"
 	"task-manager/internal/models"
+	"task-manager/internal/tokens"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
+// requestToken returns the token of the request from the Authorization
+// header or the jwt cookie, trying them in the given order, and whether it
+// came from the cookie.
+func requestToken(c *gin.Context, precedence string) (string, bool) {
+	var bearer string
+	if scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		bearer = strings.TrimSpace(token)
+	}
+	cookie, _ := c.Cookie("jwt")
+
+	if bearer != "" && (precedence != "cookie" || cookie == "") {
+		return bearer, false
+	}
+	return cookie, cookie != ""
+}
+
+// safeMethods don't change anything, so they need no CSRF token.
+var safeMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// checkCSRF makes sure a cookie-authenticated request that changes something
+// carries the CSRF token of its session in the X-CSRF-Token header. Browsers
+// send cookies along with requests made by other sites, but those sites can't
+// read the token.
+func checkCSRF(c *gin.Context, sessionID string) bool {
+	if safeMethods[c.Request.Method] {
+		return true
+	}
+
+	expected := tokens.CSRF(os.Getenv("JWT_SECRET"), sessionID)
+	return hmac.Equal([]byte(c.GetHeader("X-CSRF-Token")), []byte(expected))
+}
+
+// taskRouteScopes are the scopes of the routes that taskRoutes registers
+// under both /v1/tasks and /task, relative to those prefixes.
+var taskRouteScopes = map[string]string{
+	"GET /overdue":                           "tasks:read",
+	"GET /upcoming":                          "tasks:read",
+	"GET /assigned-to-me":                    "tasks:read",
+	"GET /trash":                             "tasks:read",
+	"POST /bulk":                             "tasks:write",
+	"GET /:id/transitions":                   "tasks:read",
+	"POST /:id/transition":                   "tasks:write",
+	"POST /:id/labels/:label_id":             "tasks:write",
+	"DELETE /:id/labels/:label_id":           "tasks:write",
+	"POST /:id/assignees":                    "tasks:write",
+	"DELETE /:id/assignees/:user_id":         "tasks:write",
+	"GET /:id/subtasks":                      "tasks:read",
+	"GET /:id/checklist":                     "tasks:read",
+	"POST /:id/checklist":                    "tasks:write",
+	"PUT /:id/checklist/:item_id":            "tasks:write",
+	"DELETE /:id/checklist/:item_id":         "tasks:write",
+	"POST /:id/dependencies":                 "tasks:write",
+	"DELETE /:id/dependencies/:blocker_id":   "tasks:write",
+	"GET /:id/graph":                         "tasks:read",
+	"GET /:id/occurrences":                   "tasks:read",
+	"GET /:id/history":                       "tasks:read",
+	"GET /:id/comments":                      "tasks:read",
+	"POST /:id/comments":                     "tasks:write",
+	"PUT /:id/comments/:comment_id":          "tasks:write",
+	"DELETE /:id/comments/:comment_id":       "tasks:write",
+	"GET /:id/comments/:comment_id/history":  "tasks:read",
+	"GET /:id/attachments":                   "tasks:read",
+	"POST /:id/attachments":                  "tasks:write",
+	"GET /:id/attachments/:attachment_id":    "tasks:read",
+	"DELETE /:id/attachments/:attachment_id": "tasks:write",
+	"POST /:id/restore":                      "tasks:write",
+	"DELETE /:id/purge":                      "tasks:write",
+}
+
+// routeScopes maps each route a personal access token can be used on to the
+// scope it needs. Routes are listed one by one rather than guessed from their
+// path, since a route under one resource can return another, such as the
+// tasks of a project.
+var routeScopes = map[string]string{
+	"GET /v1/tasks":                        "tasks:read",
+	"POST /v1/tasks":                       "tasks:write",
+	"GET /v1/tasks/:id":                    "tasks:read",
+	"PATCH /v1/tasks/:id":                  "tasks:write",
+	"DELETE /v1/tasks/:id":                 "tasks:write",
+	"POST /v1/tasks/:id/shares":            "tasks:write",
+	"DELETE /v1/tasks/:id/shares/:user_id": "tasks:write",
+
+	"GET /task/":          "tasks:read",
+	"POST /task/create":   "tasks:write",
+	"PUT /task/update":    "tasks:write",
+	"DELETE /task/delete": "tasks:write",
+	"POST /task/share":    "tasks:write",
+	"DELETE /task/share":  "tasks:write",
+
+	"GET /project/":               "projects:read",
+	"GET /project/:id":            "projects:read",
+	"GET /project/:id/stats":      "projects:read",
+	"GET /project/:id/tasks":      "tasks:read",
+	"POST /project/create":        "projects:write",
+	"PUT /project/update/:id":     "projects:write",
+	"DELETE /project/delete/:id":  "projects:write",
+	"POST /project/:id/archive":   "projects:write",
+	"POST /project/:id/unarchive": "projects:write",
+
+	"GET /label/":              "labels:read",
+	"POST /label/create":       "labels:write",
+	"PUT /label/update/:id":    "labels:write",
+	"DELETE /label/delete/:id": "labels:write",
+
+	"GET /team/":                                  "teams:read",
+	"GET /team/:id":                               "teams:read",
+	"POST /team/create":                           "teams:write",
+	"PUT /team/update/:id":                        "teams:write",
+	"DELETE /team/delete/:id":                     "teams:write",
+	"GET /team/:id/invitations":                   "teams:read",
+	"POST /team/:id/invitations":                  "teams:write",
+	"DELETE /team/:id/invitations/:invitation_id": "teams:write",
+	"POST /team/invitations/accept":               "teams:write",
+	"PUT /team/:id/members/:user_id":              "teams:write",
+	"DELETE /team/:id/members/:user_id":           "teams:write",
+	"POST /team/:id/leave":                        "teams:write",
+	"POST /team/:id/transfer":                     "teams:write",
+
+	"GET /user/mentions": "tasks:read",
+	"GET /user/activity": "tasks:read",
+}
+
+// requiredScope returns the scope a personal access token needs for the
+// route, or "" when the route can't be used with one at all, such as the
+// routes managing the account and its tokens.
+func requiredScope(method string, route string) string {
+	if scope, ok := routeScopes[method+" "+route]; ok {
+		return scope
+	}
+	for _, prefix := range []string{"/v1/tasks", "/task"} {
+		if path, ok := strings.CutPrefix(route, prefix); ok {
+			return taskRouteScopes[method+" "+path]
+		}
+	}
+	return ""
+}
+
+// issuedBy reports whether a token with the iat claim was issued by the given
+// time. The claim holds fractions of a second, so logging in again right
+// after logging out everywhere works within the same second.
+func issuedBy(issuedAt float64, at time.Time) bool {
+	return issuedAt <= float64(at.UnixMicro())/1e6
+}
+
+// lastUsedPrecision limits how often using a token is written down.
+const lastUsedPrecision = time.Minute
+
+// authenticateToken is AuthMiddleware for personal access tokens.
+func authenticateToken(c *gin.Context, secret string) {
+	now := time.Now()
+
+	var token models.PersonalAccessToken
+	err := config.DB.First(&token, "token_hash = ?", tokens.Hash(secret)).Error
+	if err != nil || (token.ExpiresAt != nil && !now.Before(*token.ExpiresAt)) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	// Find user owning the token
+	var user models.User
+	config.DB.First(&user, "id = ?", token.UserID)
+
+	if user.ID == 0 {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	scope := requiredScope(c.Request.Method, c.FullPath())
+	if scope == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Personal access tokens can't be used here",
+		})
+		return
+	}
+	if !token.Allows(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Token is missing the " + scope + " scope",
+		})
+		return
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedPrecision {
+		if err := config.DB.Model(&token).UpdateColumn("last_used_at", now).Error; err != nil {
+			log.Println("❌ Failed to record token use:", err)
+		}
+	}
+
+	// Attach user to request
+	c.Set("user_id", user.ID)
+	c.Set("token_scopes", token.Scopes)
+
+	// Continue
+	c.Next()
+}
+
 func AuthMiddleware(c *gin.Context) {
-	// Get cookie from request
-	tokenString, err := c.Cookie("jwt")
+	// Get token from the Authorization header or the cookie
+	tokenString, fromCookie := requestToken(c, config.AuthPrecedence)
 
-	if err != nil {
+	if tokenString == "" {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 
-	// Validate cookie
+	// Personal access tokens are opaque and only sent in the header
+	if !fromCookie && strings.HasPrefix(tokenString, tokens.PersonalAccessPrefix) {
+		authenticateToken(c, tokenString)
+		return
+	}
+
+	// Validate token
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -38,6 +247,14 @@ func AuthMiddleware(c *gin.Context) {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
+		// Tokens without an ID can't be revoked
+		jti, _ := claims["jti"].(string)
+		issuedAt, _ := claims["iat"].(float64)
+		if jti == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
 		// Find user with token subject
 		var user models.User
 		config.DB.First(&user, "id = ?", claims["sub"])
@@ -47,8 +264,33 @@ func AuthMiddleware(c *gin.Context) {
 			return
 		}
 
+		// Reject tokens issued before the user logged out everywhere
+		if user.SessionsRevokedAt != nil && issuedBy(issuedAt, *user.SessionsRevokedAt) {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		// Reject tokens that were logged out
+		revoked, err := config.Revocations.Revoked(jti, time.Now())
+		if err != nil || revoked {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		// Cookies are sent by the browser on its own, so they need a CSRF token
+		sessionID, _ := claims["sid"].(string)
+		if fromCookie && !checkCSRF(c, sessionID) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "CSRF token is missing or invalid",
+			})
+			return
+		}
+
 		// Attach user to request
 		c.Set("user_id", user.ID)
+		c.Set("jti", jti)
+		c.Set("session_id", sessionID)
+		c.Set("token_expires_at", time.Unix(int64(claims["exp"].(float64)), 0))
 
 		// Continue
 		c.Next()
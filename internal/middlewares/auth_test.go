@@ -0,0 +1,139 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"task-manager/internal/tokens"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testContext(method string, header http.Header, cookie string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	c.Request = httptest.NewRequest(method, "/v1/tasks", nil)
+	for name, values := range header {
+		c.Request.Header[name] = values
+	}
+	if cookie != "" {
+		c.Request.AddCookie(&http.Cookie{Name: "jwt", Value: cookie})
+	}
+	return c
+}
+
+func TestRequestToken(t *testing.T) {
+	bearer := http.Header{"Authorization": {"Bearer header-token"}}
+
+	tests := []struct {
+		name       string
+		header     http.Header
+		cookie     string
+		precedence string
+		want       string
+		fromCookie bool
+	}{
+		{name: "Header", header: bearer, precedence: "header", want: "header-token"},
+		{name: "Cookie", cookie: "cookie-token", precedence: "header", want: "cookie-token", fromCookie: true},
+		{name: "Both Prefer Header", header: bearer, cookie: "cookie-token", precedence: "header", want: "header-token"},
+		{name: "Both Prefer Cookie", header: bearer, cookie: "cookie-token", precedence: "cookie", want: "cookie-token", fromCookie: true},
+		{name: "Header When Cookie Preferred", header: bearer, precedence: "cookie", want: "header-token"},
+		{name: "Lowercase Scheme", header: http.Header{"Authorization": {"bearer header-token"}}, precedence: "header", want: "header-token"},
+		{name: "Other Scheme", header: http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}}, cookie: "cookie-token", precedence: "header", want: "cookie-token", fromCookie: true},
+		{name: "Empty Bearer", header: http.Header{"Authorization": {"Bearer "}}, precedence: "header", want: ""},
+		{name: "Nothing", precedence: "header", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, fromCookie := requestToken(testContext("GET", tt.header, tt.cookie), tt.precedence)
+			assert.Equal(t, tt.want, token)
+			assert.Equal(t, tt.fromCookie, fromCookie)
+		})
+	}
+}
+
+func TestCheckCSRF(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	valid := tokens.CSRF("secret", "session")
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		want   bool
+	}{
+		{name: "Safe Method", method: "GET", want: true},
+		{name: "Missing Token", method: "POST", want: false},
+		{name: "Valid Token", method: "PATCH", token: valid, want: true},
+		{name: "Other Session", method: "DELETE", token: tokens.CSRF("secret", "other"), want: false},
+		{name: "Other Secret", method: "PUT", token: tokens.CSRF("guess", "session"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.token != "" {
+				header.Set("X-CSRF-Token", tt.token)
+			}
+			assert.Equal(t, tt.want, checkCSRF(testContext(tt.method, header, "cookie-token"), "session"))
+		})
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		route  string
+		want   string
+	}{
+		{method: "GET", route: "/v1/tasks", want: "tasks:read"},
+		{method: "PATCH", route: "/v1/tasks/:id", want: "tasks:write"},
+		{method: "POST", route: "/task/create", want: "tasks:write"},
+		{method: "GET", route: "/task/:id/comments", want: "tasks:read"},
+		{method: "DELETE", route: "/v1/tasks/:id/purge", want: "tasks:write"},
+		{method: "GET", route: "/project/:id/stats", want: "projects:read"},
+		{method: "GET", route: "/project/:id/tasks", want: "tasks:read"},
+		{method: "DELETE", route: "/label/delete/:id", want: "labels:write"},
+		{method: "POST", route: "/team/:id/invitations", want: "teams:write"},
+		{method: "GET", route: "/user/activity", want: "tasks:read"},
+		{method: "POST", route: "/user/tokens", want: ""},
+		{method: "PUT", route: "/user/logout", want: ""},
+		{method: "DELETE", route: "/user/delete", want: ""},
+		{method: "GET", route: "/task/:id", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.route, func(t *testing.T) {
+			assert.Equal(t, tt.want, requiredScope(tt.method, tt.route))
+		})
+	}
+}
+
+func TestIssuedBy(t *testing.T) {
+	revokedAt := time.Date(2024, 5, 3, 17, 0, 0, 400_000_000, time.UTC)
+	seconds := float64(revokedAt.Unix())
+
+	tests := []struct {
+		name     string
+		issuedAt float64
+		want     bool
+	}{
+		{name: "Earlier Second", issuedAt: seconds - 1, want: true},
+		{name: "Same Second Before", issuedAt: seconds + 0.1, want: true},
+		{name: "Same Instant", issuedAt: seconds + 0.4, want: true},
+		{name: "Same Second After", issuedAt: seconds + 0.5, want: false},
+		{name: "Later Second", issuedAt: seconds + 1, want: false},
+		// Tokens issued before iat had fractions are revoked for their whole second
+		{name: "Whole Seconds", issuedAt: seconds, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, issuedBy(tt.issuedAt, revokedAt))
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks the responses of routes that were replaced by the API
+// under successor, following the Deprecation header draft and RFC 8288 links.
+// Handlers can check "deprecated_route" to keep their old response format.
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Set("deprecated_route", true)
+
+		c.Next()
+	}
+}
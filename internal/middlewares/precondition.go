@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch rejects requests without an If-Match header with 428, so
+// clients can't overwrite changes they haven't seen (RFC 6585). When required
+// is false the header stays optional and the handler honours it if present.
+func RequireIfMatch(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && c.GetHeader("If-Match") == "" {
+			c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{
+				"error": "If-Match header is required",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"net/http"
+	"task-manager/config"
+	"task-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TeamRoleMiddleware lets a request through when the user belongs to the team
+// in the :id path parameter with at least the given role, and attaches the
+// team and the user's role to the request. It must run after AuthMiddleware.
+func TeamRoleMiddleware(role models.TeamRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		// Find membership of the user in the team
+		var membership models.Membership
+		err := config.DB.First(&membership, "team_id = ? AND user_id = ?", c.Param("id"), userID).Error
+
+		// Teams the user isn't part of are reported as missing
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error": "Team not found",
+			})
+			return
+		}
+
+		if !membership.Role.AtLeast(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Your team role doesn't allow this",
+			})
+			return
+		}
+
+		c.Set("team_id", membership.TeamID)
+		c.Set("team_role", membership.Role)
+
+		c.Next()
+	}
+}
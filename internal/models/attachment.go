@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+type Attachment struct {
+	gorm.Model
+	TaskID      uint   `json:"task_id" gorm:"not null;index"`
+	UploadedBy  uint   `json:"uploaded_by" gorm:"not null"`
+	Filename    string `json:"filename" gorm:"not null"`
+	ContentType string `json:"content_type" gorm:"not null"` // sniffed from the content, not taken from the client
+	Size        int64  `json:"size" gorm:"not null"`
+	Checksum    string `json:"checksum" gorm:"not null"` // hex SHA-256 of the content
+	StorageKey  string `json:"-" gorm:"not null;uniqueIndex"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "gorm.io/gorm"
+
+type ChecklistItem struct {
+	gorm.Model
+	TaskID   uint   `json:"task_id" gorm:"not null;index"`
+	Text     string `json:"text" gorm:"not null"`
+	Done     bool   `json:"done" gorm:"not null;default:false"`
+	Position int    `json:"position" gorm:"not null;default:0"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Comment struct {
+	gorm.Model
+	TaskID   uint             `json:"task_id" gorm:"not null;index"`
+	AuthorID uint             `json:"author_id" gorm:"not null;index"`
+	Body     string           `json:"body" gorm:"not null"`
+	EditedAt *time.Time       `json:"edited_at"`
+	Mentions []CommentMention `json:"mentions" gorm:"foreignKey:CommentID"`
+}
+
+// CommentRevision keeps the text a comment had before an edit.
+type CommentRevision struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;index"`
+	Body      string    `json:"body" gorm:"not null"`
+	EditedBy  uint      `json:"edited_by" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CommentMention struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_comment_mentions_comment_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_comment_mentions_comment_user;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
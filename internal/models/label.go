@@ -0,0 +1,11 @@
+package models
+
+import "gorm.io/gorm"
+
+type Label struct {
+	gorm.Model
+	Name    string `json:"name" gorm:"not null;uniqueIndex:idx_labels_owner_name"`
+	Color   string `json:"color" gorm:"not null;default:'#808080'"`
+	OwnerID uint   `json:"owner_id" gorm:"not null;uniqueIndex:idx_labels_owner_name"`
+	TeamID  *uint  `json:"team_id" gorm:"index"`
+}
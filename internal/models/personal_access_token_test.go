@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalAccessTokenAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{name: "Granted", scopes: []string{"tasks:read"}, scope: "tasks:read", want: true},
+		{name: "Read Only", scopes: []string{"tasks:read"}, scope: "tasks:write", want: false},
+		{name: "Write Includes Read", scopes: []string{"tasks:write"}, scope: "tasks:read", want: true},
+		{name: "Other Resource", scopes: []string{"projects:write"}, scope: "tasks:read", want: false},
+		{name: "No Scopes", scope: "tasks:read", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PersonalAccessToken{Scopes: tt.scopes}.Allows(tt.scope))
+		})
+	}
+}
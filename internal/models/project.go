@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Project struct {
+	gorm.Model
+	Name        string     `json:"name" gorm:"not null"`
+	Description string     `json:"description"`
+	OwnerID     uint       `json:"owner_id" gorm:"not null;index"`
+	TeamID      *uint      `json:"team_id" gorm:"index"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+}
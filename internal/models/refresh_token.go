@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken is a long-lived opaque token that can be exchanged once for a
+// new access token and refresh token. The tokens handed out for one login
+// share a FamilyID, so the whole chain can be revoked when a used token shows
+// up again. Only the hash of the token is stored.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"size:64;not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
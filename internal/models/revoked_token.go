@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RevokedToken is a JWT that was logged out before it expired. Rows can be
+// removed once ExpiresAt has passed.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey;size:64"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -6,12 +6,83 @@ import (
 	"gorm.io/gorm"
 )
 
+type TaskStatus string
+
+const (
+	StatusNew       TaskStatus = "new"
+	StatusOngoing   TaskStatus = "ongoing"
+	StatusBlocked   TaskStatus = "blocked"
+	StatusCompleted TaskStatus = "completed"
+	StatusCancelled TaskStatus = "cancelled"
+)
+
+var TaskStatuses = []TaskStatus{StatusNew, StatusOngoing, StatusBlocked, StatusCompleted, StatusCancelled}
+
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "low"
+	PriorityNormal TaskPriority = "normal"
+	PriorityHigh   TaskPriority = "high"
+	PriorityUrgent TaskPriority = "urgent"
+)
+
+var TaskPriorities = []TaskPriority{PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent}
+
+func (p TaskPriority) Valid() bool {
+	for _, priority := range TaskPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+var ClosedTaskStatuses = []TaskStatus{StatusCompleted, StatusCancelled}
+
+func (s TaskStatus) Valid() bool {
+	for _, status := range TaskStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 type Task struct {
 	gorm.Model
-	Title       string `json:"title" gorm:"not null"`
-	Description string `'json:"description" gorm:"not null"`
-	CreatedBy   uint   `json:"created_by" gorm:"not null;default:0"`
-	User        User   `json:"user" gorm:"foreignKey:CreatedBy;references:id"`
-	Date        time.Time
-	Status      int `json:"status" gorm:"default:0"` // 0 = new, 1 = ongoing, 2 = completed
+	Title           string `json:"title" gorm:"not null"`
+	Description     string `json:"description" gorm:"not null"`
+	CreatedBy       uint   `json:"created_by" gorm:"not null;default:0;index"`
+	User            User   `json:"user" gorm:"foreignKey:CreatedBy;references:id"`
+	Date            time.Time
+	Status          TaskStatus     `json:"status" gorm:"type:varchar(20);not null;default:'new';index"`
+	StatusChangedAt time.Time      `json:"status_changed_at"`
+	StartAt         *time.Time     `json:"start_at"`
+	DueAt           *time.Time     `json:"due_at" gorm:"index"`
+	DueTimezone     string         `json:"due_timezone"`
+	RemindedAt      *time.Time     `json:"reminded_at"`
+	Priority        TaskPriority   `json:"priority" gorm:"type:varchar(20);not null;default:'normal';index"`
+	Urgency         float64        `json:"urgency" gorm:"->;-:migration"` // computed when loaded, like the counts below
+	Labels          []Label        `json:"labels" gorm:"many2many:task_labels"`
+	ProjectID       *uint          `json:"project_id" gorm:"index"`
+	Assignees       []TaskAssignee `json:"assignees" gorm:"foreignKey:TaskID"`
+	Version         uint           `json:"version" gorm:"not null;default:1"` // bumped on every change, sent as the ETag
+
+	ParentID             *uint           `json:"parent_id" gorm:"index"`
+	CompleteWithSubtasks bool            `json:"complete_with_subtasks" gorm:"not null;default:false"` // complete the task once all subtasks are
+	Checklist            []ChecklistItem `json:"checklist,omitempty" gorm:"foreignKey:TaskID"`
+	SubtasksTotal        int             `json:"subtasks_total" gorm:"->;-:migration"`
+	SubtasksDone         int             `json:"subtasks_done" gorm:"->;-:migration"`
+	ChecklistTotal       int             `json:"checklist_total" gorm:"->;-:migration"`
+	ChecklistDone        int             `json:"checklist_done" gorm:"->;-:migration"`
+
+	Recurrence           string `json:"recurrence"` // RRULE subset, see recurrence.Rule
+	SeriesID             *uint  `json:"series_id" gorm:"index"`
+	PreviousOccurrenceID *uint  `json:"previous_occurrence_id" gorm:"index"`
+}
+
+// Open reports whether work on the task is still expected.
+func (t Task) Open() bool {
+	return t.Status != StatusCompleted && t.Status != StatusCancelled
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TaskAssignee marks a user as responsible for working on a task.
+type TaskAssignee struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	TaskID     uint      `json:"task_id" gorm:"not null;uniqueIndex:idx_task_assignees_task_user"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_task_assignees_task_user;index"`
+	AssignedBy uint      `json:"assigned_by" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TaskDependency says that the task can't be completed before BlockedByID is.
+type TaskDependency struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	TaskID      uint      `json:"task_id" gorm:"not null;uniqueIndex:idx_task_dependencies_pair"`
+	BlockedByID uint      `json:"blocked_by_id" gorm:"not null;uniqueIndex:idx_task_dependencies_pair;index"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TaskHistoryAction string
+
+const (
+	HistoryCreated  TaskHistoryAction = "created"
+	HistoryUpdated  TaskHistoryAction = "updated"
+	HistoryDeleted  TaskHistoryAction = "deleted"
+	HistoryRestored TaskHistoryAction = "restored"
+	HistoryPurged   TaskHistoryAction = "purged"
+)
+
+var ErrHistoryImmutable = errors.New("task history can't be changed")
+
+// TaskHistory is one entry of a task's audit trail. Updates record one entry
+// per changed field; creating, deleting, restoring and purging record a
+// single entry without a field. Purges by the retention job have user 0.
+// Entries are never changed or removed once written.
+type TaskHistory struct {
+	ID        uint              `json:"id" gorm:"primarykey"`
+	TaskID    uint              `json:"task_id" gorm:"not null;index"`
+	UserID    uint              `json:"user_id" gorm:"not null;index"`
+	Action    TaskHistoryAction `json:"action" gorm:"type:varchar(20);not null"`
+	Field     string            `json:"field,omitempty"`
+	OldValue  string            `json:"old_value,omitempty"`
+	NewValue  string            `json:"new_value,omitempty"`
+	CreatedAt time.Time         `json:"created_at" gorm:"index"`
+}
+
+func (TaskHistory) TableName() string {
+	return "task_history"
+}
+
+func (TaskHistory) BeforeUpdate(*gorm.DB) error {
+	return ErrHistoryImmutable
+}
+
+func (TaskHistory) BeforeDelete(*gorm.DB) error {
+	return ErrHistoryImmutable
+}
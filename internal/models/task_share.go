@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+type TaskShare struct {
+	gorm.Model
+	TaskID  uint `json:"task_id" gorm:"not null;uniqueIndex:idx_task_shares_task_user"`
+	UserID  uint `json:"user_id" gorm:"not null;uniqueIndex:idx_task_shares_task_user;index"`
+	CanEdit bool `json:"can_edit" gorm:"not null;default:false"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TaskStatusChange records the moment a task entered a status.
+type TaskStatusChange struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	TaskID     uint       `json:"task_id" gorm:"not null;index"`
+	FromStatus TaskStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   TaskStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	ChangedBy  uint       `json:"changed_by" gorm:"not null"`
+	EnteredAt  time.Time  `json:"entered_at" gorm:"not null"`
+}
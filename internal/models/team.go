@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TeamRole string
+
+const (
+	RoleViewer TeamRole = "viewer"
+	RoleMember TeamRole = "member"
+	RoleAdmin  TeamRole = "admin"
+	RoleOwner  TeamRole = "owner"
+)
+
+// TeamRoles is ordered from the least to the most privileged role.
+var TeamRoles = []TeamRole{RoleViewer, RoleMember, RoleAdmin, RoleOwner}
+
+func (r TeamRole) rank() int {
+	for i, role := range TeamRoles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r TeamRole) Valid() bool {
+	return r.rank() >= 0
+}
+
+// AtLeast reports whether the role grants everything the other role does.
+func (r TeamRole) AtLeast(other TeamRole) bool {
+	return r.Valid() && r.rank() >= other.rank()
+}
+
+// RolesAtLeast lists the role and every role above it.
+func RolesAtLeast(r TeamRole) []TeamRole {
+	return TeamRoles[max(r.rank(), 0):]
+}
+
+type Team struct {
+	gorm.Model
+	Name    string       `json:"name" gorm:"not null"`
+	Members []Membership `json:"members,omitempty"`
+}
+
+type Membership struct {
+	gorm.Model
+	TeamID uint     `json:"team_id" gorm:"not null;uniqueIndex:idx_memberships_team_user"`
+	UserID uint     `json:"user_id" gorm:"not null;uniqueIndex:idx_memberships_team_user;index"`
+	User   User     `json:"-"`
+	Role   TeamRole `json:"role" gorm:"type:varchar(20);not null"`
+}
+
+type TeamInvitation struct {
+	gorm.Model
+	TeamID     uint       `json:"team_id" gorm:"not null;index"`
+	Email      string     `json:"email" gorm:"not null"`
+	Role       TeamRole   `json:"role" gorm:"type:varchar(20);not null"`
+	TokenHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	InvitedBy  uint       `json:"invited_by" gorm:"not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
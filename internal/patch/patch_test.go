@@ -0,0 +1,193 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	// Examples from RFC 7396 appendix A
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+	}{
+		{name: "Replace Member", document: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "Add Member", document: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "Remove Member", document: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{name: "Remove One Of Two", document: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{name: "Replace Array", document: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "Replace With Array", document: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{name: "Nested", document: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{name: "Arrays Are Replaced", document: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{name: "Non Object Patch", document: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{name: "Null Patch", document: `{"e":null}`, patch: `{"a":1}`, want: `{"a":1,"e":null}`},
+		{name: "Object Into Array", document: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{name: "Deep Null Ignored", document: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge([]byte(tt.document), []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	_, err := Merge([]byte(`{}`), []byte(`{"a":`))
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestApply(t *testing.T) {
+	// Mostly examples from RFC 6902 appendix A
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+		err      error
+	}{
+		{
+			name:     "Add Member",
+			document: `{"foo":"bar"}`,
+			patch:    `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:     `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:     "Add Array Element",
+			document: `{"foo":["bar","baz"]}`,
+			patch:    `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:     `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:     "Append Array Element",
+			document: `{"foo":["bar"]}`,
+			patch:    `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want:     `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name:     "Remove Member",
+			document: `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"remove","path":"/baz"}]`,
+			want:     `{"foo":"bar"}`,
+		},
+		{
+			name:     "Remove Array Element",
+			document: `{"foo":["bar","qux","baz"]}`,
+			patch:    `[{"op":"remove","path":"/foo/1"}]`,
+			want:     `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:     "Replace Value",
+			document: `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:     `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:     "Move Value",
+			document: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch:    `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:     `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:     "Move Array Element",
+			document: `{"foo":["all","grass","cows","eat"]}`,
+			patch:    `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:     `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:     "Copy Value",
+			document: `{"foo":{"bar":1}}`,
+			patch:    `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			want:     `{"foo":{"bar":1},"baz":{"bar":2}}`,
+		},
+		{
+			name:     "Test Passes",
+			document: `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch:    `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want:     `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:     "Test Compares Numbers",
+			document: `{"n":1}`,
+			patch:    `[{"op":"test","path":"/n","value":1.0}]`,
+			want:     `{"n":1}`,
+		},
+		{
+			name:     "Escaped Pointer",
+			document: `{"/":9,"~1":10}`,
+			patch:    `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`,
+			want:     `{"~1":10}`,
+		},
+		{
+			name:     "Test Fails",
+			document: `{"baz":"qux"}`,
+			patch:    `[{"op":"test","path":"/baz","value":"bar"}]`,
+			err:      ErrConflict,
+		},
+		{
+			name:     "Test String Against Number",
+			document: `{"baz":"1"}`,
+			patch:    `[{"op":"test","path":"/baz","value":1}]`,
+			err:      ErrConflict,
+		},
+		{
+			name:     "Missing Target",
+			document: `{"foo":"bar"}`,
+			patch:    `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			err:      ErrConflict,
+		},
+		{
+			name:     "Remove Missing",
+			document: `{"foo":"bar"}`,
+			patch:    `[{"op":"remove","path":"/baz"}]`,
+			err:      ErrConflict,
+		},
+		{
+			name:     "Index Out Of Range",
+			document: `{"foo":["bar"]}`,
+			patch:    `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			err:      ErrConflict,
+		},
+		{
+			name:     "Move Into Itself",
+			document: `{"foo":{"bar":1}}`,
+			patch:    `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`,
+			err:      ErrConflict,
+		},
+		{
+			name:     "Unknown Op",
+			document: `{}`,
+			patch:    `[{"op":"increment","path":"/foo"}]`,
+			err:      ErrInvalid,
+		},
+		{
+			name:     "Missing Value",
+			document: `{}`,
+			patch:    `[{"op":"add","path":"/foo"}]`,
+			err:      ErrInvalid,
+		},
+		{
+			name:     "Not An Array",
+			document: `{}`,
+			patch:    `{"op":"add","path":"/foo","value":1}`,
+			err:      ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.document), []byte(tt.patch))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule is the supported subset of an RFC 5545 RRULE:
+//
+//	FREQ=DAILY;INTERVAL=2
+//	FREQ=WEEKLY;BYDAY=MO,WE
+//	FREQ=MONTHLY;BYMONTHDAY=15
+//	FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION
+//
+// X-FROM=COMPLETION counts the interval from when the previous occurrence was
+// completed instead of from its due time.
+type Rule struct {
+	Freq           Frequency
+	Interval       int
+	ByDay          []time.Weekday
+	ByMonthDay     int
+	FromCompletion bool
+}
+
+func Parse(value string) (Rule, error) {
+	rule := Rule{Interval: 1}
+
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(value), "RRULE:"), ";") {
+		key, val, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return rule, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return rule, fmt.Errorf("invalid BYDAY %q", day)
+				}
+				if !slices.Contains(rule.ByDay, weekday) {
+					rule.ByDay = append(rule.ByDay, weekday)
+				}
+			}
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(val)
+			if err != nil || day < 1 || day > 31 {
+				return rule, fmt.Errorf("invalid BYMONTHDAY %q", val)
+			}
+			rule.ByMonthDay = day
+		case "X-FROM":
+			if strings.ToUpper(val) != "COMPLETION" {
+				return rule, fmt.Errorf("invalid X-FROM %q", val)
+			}
+			rule.FromCompletion = true
+		default:
+			return rule, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	switch rule.Freq {
+	case Daily, Weekly, Monthly:
+	default:
+		return rule, fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY")
+	}
+
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return rule, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if rule.ByMonthDay != 0 && rule.Freq != Monthly {
+		return rule, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+// Next returns the first occurrence after the given time, keeping its time of
+// day and location. start is the first occurrence of the series, the DTSTART
+// of RFC 5545: monthly rules without BYMONTHDAY take their day from it, so a
+// series started on the 31st comes back to the 31st after a shorter month.
+// Monthly rules on a day the month doesn't have fall on the last day of that
+// month.
+func (r Rule) Next(start, after time.Time) time.Time {
+	switch r.Freq {
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return after.AddDate(0, 0, 7*r.Interval)
+		}
+
+		// Weeks start on Monday and only every Interval-th week counts
+		weekStart := after.AddDate(0, 0, -((int(after.Weekday()) + 6) % 7))
+		for day := 1; ; day++ {
+			candidate := after.AddDate(0, 0, day)
+			week := int(candidate.Sub(weekStart).Hours()/24+0.5) / 7
+			if week%r.Interval == 0 && slices.Contains(r.ByDay, candidate.Weekday()) {
+				return candidate
+			}
+		}
+
+	case Monthly:
+		day := r.ByMonthDay
+		if day == 0 {
+			day = start.In(after.Location()).Day()
+		}
+
+		for months := 0; ; months += r.Interval {
+			first := time.Date(after.Year(), after.Month()+time.Month(months), 1,
+				after.Hour(), after.Minute(), after.Second(), after.Nanosecond(), after.Location())
+			candidate := first.AddDate(0, 0, min(day, daysIn(first))-1)
+			if candidate.After(after) {
+				return candidate
+			}
+		}
+
+	default:
+		return after.AddDate(0, 0, r.Interval)
+	}
+}
+
+func daysIn(month time.Time) int {
+	return month.AddDate(0, 1, -month.Day()).Day()
+}
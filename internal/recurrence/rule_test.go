@@ -0,0 +1,100 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		wantErr bool
+	}{
+		{name: "Daily", rule: "FREQ=DAILY"},
+		{name: "Every Two Days", rule: "FREQ=DAILY;INTERVAL=2"},
+		{name: "Weekly On Weekdays", rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{name: "Monthly On Day", rule: "FREQ=MONTHLY;BYMONTHDAY=15"},
+		{name: "After Completion", rule: "FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION"},
+		{name: "Missing Frequency", rule: "INTERVAL=2", wantErr: true},
+		{name: "Yearly", rule: "FREQ=YEARLY", wantErr: true},
+		{name: "Zero Interval", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "Unknown Weekday", rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "BYDAY On Monthly", rule: "FREQ=MONTHLY;BYDAY=MO", wantErr: true},
+		{name: "Month Day Out Of Range", rule: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{name: "Unsupported Part", rule: "FREQ=DAILY;COUNT=3", wantErr: true},
+		{name: "Malformed Part", rule: "FREQ=DAILY;INTERVAL", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.rule)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	after := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rule string
+		want time.Time
+	}{
+		{name: "Daily", rule: "FREQ=DAILY", want: time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{name: "Every Three Days", rule: "FREQ=DAILY;INTERVAL=3", want: time.Date(2024, 2, 3, 9, 30, 0, 0, time.UTC)},
+		{name: "Weekly Same Weekday", rule: "FREQ=WEEKLY", want: time.Date(2024, 2, 7, 9, 30, 0, 0, time.UTC)},
+		{name: "Later In Same Week", rule: "FREQ=WEEKLY;BYDAY=MO,FR", want: time.Date(2024, 2, 2, 9, 30, 0, 0, time.UTC)},
+		{name: "Next Week", rule: "FREQ=WEEKLY;BYDAY=MO,TU", want: time.Date(2024, 2, 5, 9, 30, 0, 0, time.UTC)},
+		{name: "Every Other Week", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", want: time.Date(2024, 2, 12, 9, 30, 0, 0, time.UTC)},
+		{name: "Monthly Same Day Clamped", rule: "FREQ=MONTHLY", want: time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC)},
+		{name: "Monthly On Day", rule: "FREQ=MONTHLY;BYMONTHDAY=15", want: time.Date(2024, 2, 15, 9, 30, 0, 0, time.UTC)},
+		{name: "Quarterly On Day", rule: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=15", want: time.Date(2024, 4, 15, 9, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, rule.Next(after, after))
+		})
+	}
+}
+
+func TestNextKeepsLocalTime(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// Clocks move forward on March 31st, the occurrence stays at 09:00 local time
+	rule, _ := Parse("FREQ=WEEKLY;BYDAY=MO")
+	start := time.Date(2024, 3, 25, 9, 0, 0, 0, loc)
+	next := rule.Next(start, start)
+	assert.Equal(t, time.Date(2024, 4, 1, 9, 0, 0, 0, loc), next)
+}
+
+func TestNextMonthlyKeepsSeriesDay(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+	rule, _ := Parse("FREQ=MONTHLY")
+
+	// Clamped to February 29th, then back to the 31st and the 30th
+	want := []time.Time{
+		time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 5, 31, 9, 30, 0, 0, time.UTC),
+	}
+
+	next := start
+	for _, expected := range want {
+		next = rule.Next(start, next)
+		assert.Equal(t, expected, next)
+	}
+}
@@ -0,0 +1,96 @@
+package reminders
+
+import (
+	"context"
+	"log"
+	"task-manager/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Event is emitted once for every open task that gets close to its due time.
+type Event struct {
+	TaskID uint      `json:"task_id"`
+	UserID uint      `json:"user_id"`
+	Title  string    `json:"title"`
+	DueAt  time.Time `json:"due_at"`
+}
+
+type Notifier interface {
+	Notify(Event) error
+}
+
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(event Event) error {
+	log.Printf("⏰ Task %d %q for user %d is due at %s", event.TaskID, event.Title, event.UserID, event.DueAt.Format(time.RFC3339))
+	return nil
+}
+
+type Scheduler struct {
+	DB       *gorm.DB
+	Notifier Notifier
+	// Interval is how often due tasks are checked
+	Interval time.Duration
+	// Lead is how long before the due time a reminder goes out
+	Lead time.Duration
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(time.Now()); err != nil {
+			log.Println("❌ Failed to send reminders:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick sends reminders for open tasks due before now+Lead that haven't had one.
+func (s *Scheduler) Tick(now time.Time) error {
+	var tasks []models.Task
+	err := s.DB.
+		Where("due_at <= ? AND reminded_at IS NULL", now.Add(s.Lead)).
+		Where("status NOT IN ?", models.ClosedTaskStatuses).
+		Order("due_at").
+		Find(&tasks).Error
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		// Claim the reminder first so concurrent servers never send it twice
+		result := s.DB.Model(&models.Task{}).
+			Where("id = ? AND reminded_at IS NULL", task.ID).
+			Updates(map[string]interface{}{"reminded_at": now, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		event := Event{TaskID: task.ID, UserID: task.CreatedBy, Title: task.Title, DueAt: *task.DueAt}
+		if err := s.Notifier.Notify(event); err != nil {
+			log.Println("❌ Failed to send reminder:", err)
+
+			// Release the claim so the next tick tries again
+			err := s.DB.Model(&models.Task{}).
+				Where("id = ? AND reminded_at = ?", task.ID, now).
+				Updates(map[string]interface{}{"reminded_at": nil, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
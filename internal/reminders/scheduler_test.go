@@ -0,0 +1,91 @@
+package reminders
+
+import (
+	"errors"
+	"strings"
+	"task-manager/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestTickSelectsOpenTasksDueWithinLead(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	assert.NoError(t, err)
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		statements = append(statements, strings.ReplaceAll(sql, `"`, ""))
+	})
+
+	scheduler := Scheduler{DB: db, Notifier: LogNotifier{}, Interval: time.Minute, Lead: 2 * time.Hour}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, scheduler.Tick(now))
+	assert.Len(t, statements, 1)
+	assert.Contains(t, statements[0], "due_at <= '2024-05-01 12:00:00' AND reminded_at IS NULL")
+	assert.Contains(t, statements[0], "status NOT IN ('completed','cancelled')")
+}
+
+type notifierFunc func(Event) error
+
+func (f notifierFunc) Notify(event Event) error {
+	return f(event)
+}
+
+func TestTickReleasesReminderWhenNotifyFails(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	assert.NoError(t, err)
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	due := now.Add(time.Hour)
+
+	var updates []string
+	db.Callback().Query().After("gorm:query").Register("test:tasks", func(tx *gorm.DB) {
+		if tasks, ok := tx.Statement.Dest.(*[]models.Task); ok {
+			task := models.Task{Title: "Ship", DueAt: &due}
+			task.ID = 3
+			*tasks = []models.Task{task}
+		}
+	})
+	db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		updates = append(updates, strings.ReplaceAll(sql, `"`, ""))
+		tx.RowsAffected = 1
+	})
+
+	tests := []struct {
+		name     string
+		notifier Notifier
+		updates  int
+	}{
+		{name: "Sent", notifier: LogNotifier{}, updates: 1},
+		{name: "Failed", notifier: notifierFunc(func(Event) error { return errors.New("offline") }), updates: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates = nil
+			scheduler := Scheduler{DB: db, Notifier: tt.notifier, Interval: time.Minute, Lead: 2 * time.Hour}
+
+			assert.NoError(t, scheduler.Tick(now))
+			assert.Len(t, updates, tt.updates)
+			assert.Contains(t, updates[0], "SET reminded_at='2024-05-01 10:00:00'")
+			if tt.updates == 2 {
+				assert.Contains(t, updates[1], "SET reminded_at=NULL")
+				assert.Contains(t, updates[1], "WHERE (id = 3 AND reminded_at = '2024-05-01 10:00:00')")
+			}
+		})
+	}
+}
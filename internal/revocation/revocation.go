@@ -0,0 +1,115 @@
+// Package revocation keeps track of JWTs that were logged out before they
+// expired.
+package revocation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"task-manager/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store keeps revoked token IDs in the database and caches lookups, so
+// AuthMiddleware doesn't need a query on every request. Revocations are
+// cached until the token expires; tokens found valid are checked again after
+// CacheTTL, which bounds how long a revocation made by another server goes
+// unnoticed.
+type Store struct {
+	DB            *gorm.DB
+	CacheTTL      time.Duration
+	PurgeInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]entry
+}
+
+type entry struct {
+	revoked   bool
+	expiresAt time.Time // for revoked tokens
+	checkedAt time.Time // for valid ones
+}
+
+func (s *Store) remember(jti string, e entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cache == nil {
+		s.cache = map[string]entry{}
+	}
+	s.cache[jti] = e
+}
+
+// Revoke rejects the token from now on.
+func (s *Store) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	err := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	s.remember(jti, entry{revoked: true, expiresAt: expiresAt})
+	return nil
+}
+
+// Revoked reports whether the token was revoked.
+func (s *Store) Revoked(jti string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[jti]
+	s.mu.Unlock()
+
+	if ok && (cached.revoked || now.Sub(cached.checkedAt) < s.CacheTTL) {
+		return cached.revoked, nil
+	}
+
+	var token models.RevokedToken
+	result := s.DB.Where("jti = ?", jti).Limit(1).Find(&token)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		s.remember(jti, entry{revoked: true, expiresAt: token.ExpiresAt})
+		return true, nil
+	}
+
+	s.remember(jti, entry{checkedAt: now})
+	return false, nil
+}
+
+// Purge forgets tokens that expired before now, as they are rejected anyway.
+func (s *Store) Purge(now time.Time) error {
+	s.mu.Lock()
+	for jti, cached := range s.cache {
+		if (cached.revoked && cached.expiresAt.Before(now)) || (!cached.revoked && now.Sub(cached.checkedAt) >= s.CacheTTL) {
+			delete(s.cache, jti)
+		}
+	}
+	s.mu.Unlock()
+
+	return s.DB.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+}
+
+// Run purges expired tokens every PurgeInterval until the context is done.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Purge(time.Now()); err != nil {
+			log.Println("❌ Failed to purge revoked tokens:", err)
+		}
+	}
+}
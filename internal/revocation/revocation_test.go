@@ -0,0 +1,82 @@
+package revocation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupDryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	assert.NoError(t, err)
+
+	statements := []string{}
+	capture := func(tx *gorm.DB) {
+		sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		statements = append(statements, strings.ReplaceAll(sql, `"`, ""))
+	}
+	db.Callback().Query().After("gorm:query").Register("test:capture_query", capture)
+	db.Callback().Delete().After("gorm:delete").Register("test:capture_delete", capture)
+	db.Callback().Create().After("gorm:create").Register("test:capture_create", capture)
+
+	return db, &statements
+}
+
+func TestRevokedCachesLookups(t *testing.T) {
+	db, statements := setupDryRunDB(t)
+	store := &Store{DB: db, CacheTTL: time.Minute}
+	now := time.Now()
+
+	// A dry run finds no revoked tokens
+	revoked, err := store.Revoked("abc", now)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Len(t, *statements, 1)
+	assert.Contains(t, (*statements)[0], "SELECT * FROM revoked_tokens WHERE jti = 'abc'")
+
+	// Valid tokens are trusted for CacheTTL
+	revoked, err = store.Revoked("abc", now.Add(30*time.Second))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Len(t, *statements, 1)
+
+	revoked, err = store.Revoked("abc", now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Len(t, *statements, 2)
+
+	// Revocations take effect at once and are never looked up again
+	assert.NoError(t, store.Revoke("abc", 7, now.Add(time.Hour)))
+	assert.Contains(t, (*statements)[2], "INSERT INTO revoked_tokens")
+	assert.Contains(t, (*statements)[2], "ON CONFLICT DO NOTHING")
+
+	revoked, err = store.Revoked("abc", now.Add(10*time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Len(t, *statements, 3)
+}
+
+func TestPurgeForgetsExpiredTokens(t *testing.T) {
+	db, statements := setupDryRunDB(t)
+	store := &Store{DB: db, CacheTTL: time.Minute}
+	now := time.Now()
+
+	assert.NoError(t, store.Revoke("expired", 7, now.Add(-time.Second)))
+	assert.NoError(t, store.Revoke("active", 7, now.Add(time.Hour)))
+
+	assert.NoError(t, store.Purge(now))
+	assert.Contains(t, (*statements)[2], "DELETE FROM revoked_tokens WHERE expires_at <")
+
+	assert.NotContains(t, store.cache, "expired")
+	assert.Contains(t, store.cache, "active")
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"task-manager/internal/handlers"
+	"task-manager/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LabelRouter(c *gin.Engine) {
+	label := c.Group("/label")
+	{
+		label.POST("/create", middlewares.AuthMiddleware, handlers.CreateLabel)
+		label.GET("/", middlewares.AuthMiddleware, handlers.GetLabels)
+		label.PUT("/update/:id", middlewares.AuthMiddleware, handlers.UpdateLabel)
+		label.DELETE("/delete/:id", middlewares.AuthMiddleware, handlers.DeleteLabel)
+	}
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"task-manager/internal/handlers"
+	"task-manager/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ProjectRouter(c *gin.Engine) {
+	project := c.Group("/project")
+	{
+		project.POST("/create", middlewares.AuthMiddleware, handlers.CreateProject)
+		project.GET("/", middlewares.AuthMiddleware, handlers.GetProjects)
+		project.GET("/:id", middlewares.AuthMiddleware, handlers.GetProject)
+		project.PUT("/update/:id", middlewares.AuthMiddleware, handlers.UpdateProject)
+		project.DELETE("/delete/:id", middlewares.AuthMiddleware, handlers.DeleteProject)
+		project.POST("/:id/archive", middlewares.AuthMiddleware, handlers.ArchiveProject)
+		project.POST("/:id/unarchive", middlewares.AuthMiddleware, handlers.UnarchiveProject)
+		project.GET("/:id/tasks", middlewares.AuthMiddleware, handlers.GetProjectTasks)
+		project.GET("/:id/stats", middlewares.AuthMiddleware, handlers.GetProjectStats)
+	}
+}
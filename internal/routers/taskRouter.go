@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"task-manager/config"
 	"task-manager/internal/handlers"
 	"task-manager/internal/middlewares"
 
@@ -8,11 +9,65 @@ import (
 )
 
 func TaskRouter(c *gin.Engine) {
-	task := c.Group("/task")
+	ifMatch := middlewares.RequireIfMatch(config.GetBool("REQUIRE_IF_MATCH", false))
+
+	tasks := c.Group("/v1/tasks")
+	{
+		tasks.GET("", middlewares.AuthMiddleware, handlers.GetTasks)
+		tasks.POST("", middlewares.AuthMiddleware, handlers.CreateTask)
+		tasks.GET("/:id", middlewares.AuthMiddleware, handlers.GetTask)
+		tasks.PATCH("/:id", middlewares.AuthMiddleware, ifMatch, handlers.PatchTask)
+		tasks.DELETE("/:id", middlewares.AuthMiddleware, ifMatch, handlers.DeleteTask)
+		tasks.POST("/:id/shares", middlewares.AuthMiddleware, handlers.ShareTask)
+		tasks.DELETE("/:id/shares/:user_id", middlewares.AuthMiddleware, handlers.UnshareTask)
+		taskRoutes(tasks)
+	}
+
+	// The original routes, which take the task ID as a query parameter
+	task := c.Group("/task", middlewares.Deprecated("/v1/tasks"))
 	{
 		task.POST("/create", middlewares.AuthMiddleware, handlers.CreateTask)
 		task.GET("/", middlewares.AuthMiddleware, handlers.GetTasks)
 		task.DELETE("/delete", middlewares.AuthMiddleware, handlers.DeleteTask)
 		task.PUT("/update", middlewares.AuthMiddleware, handlers.UpdateTasks)
+		task.POST("/share", middlewares.AuthMiddleware, handlers.ShareTask)
+		task.DELETE("/share", middlewares.AuthMiddleware, handlers.UnshareTask)
+		taskRoutes(task)
 	}
 }
+
+// taskRoutes registers the views and task sub-resources that both API versions share.
+func taskRoutes(task *gin.RouterGroup) {
+	task.GET("/overdue", middlewares.AuthMiddleware, handlers.GetOverdueTasks)
+	task.GET("/upcoming", middlewares.AuthMiddleware, handlers.GetUpcomingTasks)
+	task.GET("/assigned-to-me", middlewares.AuthMiddleware, handlers.GetAssignedTasks)
+	task.GET("/trash", middlewares.AuthMiddleware, handlers.GetTrash)
+	task.POST("/bulk", middlewares.AuthMiddleware, handlers.BulkTasks)
+	task.POST("/:id/transition", middlewares.AuthMiddleware, handlers.TransitionTask)
+	task.GET("/:id/transitions", middlewares.AuthMiddleware, handlers.GetTaskTransitions)
+	task.POST("/:id/labels/:label_id", middlewares.AuthMiddleware, handlers.AttachLabel)
+	task.DELETE("/:id/labels/:label_id", middlewares.AuthMiddleware, handlers.DetachLabel)
+	task.GET("/:id/subtasks", middlewares.AuthMiddleware, handlers.GetSubtasks)
+	task.GET("/:id/checklist", middlewares.AuthMiddleware, handlers.GetChecklist)
+	task.POST("/:id/checklist", middlewares.AuthMiddleware, handlers.CreateChecklistItem)
+	task.PUT("/:id/checklist/:item_id", middlewares.AuthMiddleware, handlers.UpdateChecklistItem)
+	task.DELETE("/:id/checklist/:item_id", middlewares.AuthMiddleware, handlers.DeleteChecklistItem)
+	task.POST("/:id/dependencies", middlewares.AuthMiddleware, handlers.AddDependency)
+	task.DELETE("/:id/dependencies/:blocker_id", middlewares.AuthMiddleware, handlers.RemoveDependency)
+	task.GET("/:id/graph", middlewares.AuthMiddleware, handlers.GetDependencyGraph)
+	task.GET("/:id/occurrences", middlewares.AuthMiddleware, handlers.GetOccurrences)
+	task.GET("/:id/history", middlewares.AuthMiddleware, handlers.GetTaskHistory)
+	task.POST("/:id/restore", middlewares.AuthMiddleware, handlers.RestoreTask)
+	task.DELETE("/:id/purge", middlewares.AuthMiddleware, handlers.PurgeTask)
+	task.POST("/:id/assignees", middlewares.AuthMiddleware, handlers.AssignTask)
+	task.DELETE("/:id/assignees/:user_id", middlewares.AuthMiddleware, handlers.UnassignTask)
+	task.GET("/:id/comments", middlewares.AuthMiddleware, handlers.GetComments)
+	task.POST("/:id/comments", middlewares.AuthMiddleware, handlers.CreateComment)
+	task.PUT("/:id/comments/:comment_id", middlewares.AuthMiddleware, handlers.UpdateComment)
+	task.DELETE("/:id/comments/:comment_id", middlewares.AuthMiddleware, handlers.DeleteComment)
+	task.GET("/:id/comments/:comment_id/history", middlewares.AuthMiddleware, handlers.GetCommentHistory)
+	task.GET("/:id/attachments", middlewares.AuthMiddleware, handlers.GetAttachments)
+	task.POST("/:id/attachments", middlewares.AuthMiddleware, handlers.UploadAttachment)
+	task.GET("/:id/attachments/:attachment_id", middlewares.AuthMiddleware, handlers.DownloadAttachment)
+	task.DELETE("/:id/attachments/:attachment_id", middlewares.AuthMiddleware, handlers.DeleteAttachment)
+}
@@ -0,0 +1,32 @@
+package routers
+
+import (
+	"task-manager/internal/handlers"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TeamRouter(c *gin.Engine) {
+	viewer := middlewares.TeamRoleMiddleware(models.RoleViewer)
+	admin := middlewares.TeamRoleMiddleware(models.RoleAdmin)
+	owner := middlewares.TeamRoleMiddleware(models.RoleOwner)
+
+	team := c.Group("/team")
+	{
+		team.POST("/create", middlewares.AuthMiddleware, handlers.CreateTeam)
+		team.GET("/", middlewares.AuthMiddleware, handlers.GetTeams)
+		team.POST("/invitations/accept", middlewares.AuthMiddleware, handlers.AcceptInvitation)
+		team.GET("/:id", middlewares.AuthMiddleware, viewer, handlers.GetTeam)
+		team.PUT("/update/:id", middlewares.AuthMiddleware, admin, handlers.UpdateTeam)
+		team.DELETE("/delete/:id", middlewares.AuthMiddleware, owner, handlers.DeleteTeam)
+		team.POST("/:id/invitations", middlewares.AuthMiddleware, admin, handlers.InviteMember)
+		team.GET("/:id/invitations", middlewares.AuthMiddleware, admin, handlers.GetInvitations)
+		team.DELETE("/:id/invitations/:invitation_id", middlewares.AuthMiddleware, admin, handlers.RevokeInvitation)
+		team.PUT("/:id/members/:user_id", middlewares.AuthMiddleware, admin, handlers.UpdateMemberRole)
+		team.DELETE("/:id/members/:user_id", middlewares.AuthMiddleware, admin, handlers.RemoveMember)
+		team.POST("/:id/leave", middlewares.AuthMiddleware, viewer, handlers.LeaveTeam)
+		team.POST("/:id/transfer", middlewares.AuthMiddleware, owner, handlers.TransferTeamOwnership)
+	}
+}
@@ -12,7 +12,16 @@ func UserRouter(c *gin.Engine) {
 	{
 		user.POST("/register", handlers.UserRegistration)
 		user.POST("/login", handlers.UserLogin)
+		user.POST("/refresh", handlers.RefreshToken)
+		user.POST("/password/forgot", handlers.ForgotPassword)
+		user.POST("/password/reset", handlers.ResetPassword)
 		user.PUT("/logout", middlewares.AuthMiddleware, handlers.UserLogout)
+		user.PUT("/logout/all", middlewares.AuthMiddleware, handlers.UserLogoutAll)
 		user.DELETE("/delete", middlewares.AuthMiddleware, handlers.UserDelete)
+		user.GET("/mentions", middlewares.AuthMiddleware, handlers.GetMentions)
+		user.GET("/activity", middlewares.AuthMiddleware, handlers.GetActivity)
+		user.POST("/tokens", middlewares.AuthMiddleware, handlers.CreateToken)
+		user.GET("/tokens", middlewares.AuthMiddleware, handlers.GetTokens)
+		user.DELETE("/tokens/:id", middlewares.AuthMiddleware, handlers.DeleteToken)
 	}
 }
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a stand-in for an S3 compatible service that keeps objects in
+// memory and rejects requests that aren't signed for its bucket.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/bucket/")
+	if !ok {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, key, time.Time{}, bytes.NewReader(data))
+	}
+}
+
+func testBackends(t *testing.T) map[string]Storage {
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	t.Cleanup(server.Close)
+
+	return map[string]Storage{
+		"Local": Local{Root: t.TempDir()},
+		"S3":    S3{Endpoint: server.URL, Bucket: "bucket", Region: "us-east-1", AccessKey: "key", SecretKey: "secret"},
+	}
+}
+
+func TestStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("0123456789abcdefghij")
+
+	for name, store := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			err := store.Put(ctx, "tasks/1/object", bytes.NewReader(content), int64(len(content)), "text/plain")
+			assert.NoError(t, err)
+
+			object, err := store.Open(ctx, "tasks/1/object")
+			assert.NoError(t, err)
+
+			data, err := io.ReadAll(object)
+			assert.NoError(t, err)
+			assert.Equal(t, content, data)
+
+			// Ranges are served by seeking
+			_, err = object.Seek(10, io.SeekStart)
+			assert.NoError(t, err)
+			part := make([]byte, 5)
+			_, err = io.ReadFull(object, part)
+			assert.NoError(t, err)
+			assert.Equal(t, "abcde", string(part))
+
+			size, err := object.Seek(0, io.SeekEnd)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(content)), size)
+			assert.NoError(t, object.Close())
+
+			assert.NoError(t, store.Delete(ctx, "tasks/1/object"))
+			_, err = store.Open(ctx, "tasks/1/object")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			// Deleting twice is fine
+			assert.NoError(t, store.Delete(ctx, "tasks/1/object"))
+		})
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	store := Local{Root: t.TempDir()}
+
+	err := store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain")
+	assert.Error(t, err)
+
+	_, err = store.Open(context.Background(), "/etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestLocalRejectsShortWrites(t *testing.T) {
+	store := Local{Root: t.TempDir()}
+
+	err := store.Put(context.Background(), "short", strings.NewReader("abc"), 10, "text/plain")
+	assert.Error(t, err)
+
+	_, err = store.Open(context.Background(), "short")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
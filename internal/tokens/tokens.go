@@ -0,0 +1,36 @@
+package tokens
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// PersonalAccessPrefix starts every personal access token, which tells them
+// apart from JWTs.
+const PersonalAccessPrefix = "pat_"
+
+// Generate returns a random token with the given prefix. Only its Hash should
+// be stored, the token itself is shown to the user once.
+func Generate(prefix string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(secret), nil
+}
+
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CSRF derives the CSRF token of a session, which cookie-authenticated
+// requests have to echo in a header. Deriving it from the session ID keeps it
+// stable across token refreshes without storing it.
+func CSRF(secret string, sessionID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("csrf:" + sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,136 @@
+package trash
+
+import (
+	"context"
+	"log"
+	"task-manager/internal/models"
+	"task-manager/internal/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// batchSize limits how many tasks one transaction of the janitor purges.
+const batchSize = 100
+
+// Purge permanently deletes the tasks and everything attached to them. It
+// should run inside a transaction and returns the storage keys of the
+// attachment content, to be removed with RemoveContent once it committed.
+// The history of the tasks is kept and gets a purged entry by userID, or by
+// user 0 when the retention window ran out.
+func Purge(tx *gorm.DB, taskIDs []uint, userID uint) ([]string, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	err := tx.Unscoped().Model(&models.Attachment{}).Where("task_id IN ?", taskIDs).Pluck("storage_key", &keys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	comments := tx.Session(&gorm.Session{NewDB: true}).
+		Unscoped().
+		Model(&models.Comment{}).
+		Select("id").
+		Where("task_id IN ?", taskIDs)
+
+	deletes := []*gorm.DB{
+		tx.Where("comment_id IN (?)", comments).Delete(&models.CommentMention{}),
+		tx.Where("comment_id IN (?)", comments).Delete(&models.CommentRevision{}),
+		tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&models.Comment{}),
+		tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&models.Attachment{}),
+		tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&models.ChecklistItem{}),
+		tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&models.TaskShare{}),
+		tx.Where("task_id IN ?", taskIDs).Delete(&models.TaskAssignee{}),
+		tx.Where("task_id IN ? OR blocked_by_id IN ?", taskIDs, taskIDs).Delete(&models.TaskDependency{}),
+		tx.Where("task_id IN ?", taskIDs).Delete(&models.TaskStatusChange{}),
+		tx.Exec("DELETE FROM task_labels WHERE task_id IN ?", taskIDs),
+		// Subtasks outlive their parent as top level tasks
+		tx.Unscoped().Model(&models.Task{}).Where("parent_id IN ?", taskIDs).Updates(map[string]interface{}{
+			"parent_id": nil,
+			"version":   gorm.Expr("version + 1"),
+		}),
+		tx.Unscoped().Where("id IN ?", taskIDs).Delete(&models.Task{}),
+	}
+	for _, result := range deletes {
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+
+	history := make([]models.TaskHistory, len(taskIDs))
+	for i, id := range taskIDs {
+		history[i] = models.TaskHistory{TaskID: id, UserID: userID, Action: models.HistoryPurged}
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RemoveContent deletes purged attachment content. Failures are only logged,
+// since nothing refers to the content anymore.
+func RemoveContent(ctx context.Context, store storage.Storage, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("❌ Failed to delete attachment content %s: %v", key, err)
+		}
+	}
+}
+
+// Janitor purges tasks that have been in the trash for longer than Retention.
+type Janitor struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+	// Interval is how often the trash is checked
+	Interval time.Duration
+	// Retention is how long deleted tasks can be restored
+	Retention time.Duration
+}
+
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.Tick(ctx, time.Now()); err != nil {
+			log.Println("❌ Failed to purge trash:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick purges every task deleted before now-Retention, a batch at a time.
+func (j *Janitor) Tick(ctx context.Context, now time.Time) error {
+	for {
+		var ids []uint
+		err := j.DB.Unscoped().Model(&models.Task{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", now.Add(-j.Retention)).
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		var keys []string
+		err = j.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			keys, err = Purge(tx, ids, 0)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		RemoveContent(ctx, j.Storage, keys)
+		log.Printf("🗑️ Purged %d tasks from the trash", len(ids))
+	}
+}
@@ -0,0 +1,76 @@
+package trash
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupDryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	assert.NoError(t, err)
+
+	statements := []string{}
+	capture := func(tx *gorm.DB) {
+		sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		statements = append(statements, strings.ReplaceAll(sql, `"`, ""))
+	}
+	db.Callback().Query().After("gorm:query").Register("test:capture_query", capture)
+	db.Callback().Update().After("gorm:update").Register("test:capture_update", capture)
+	db.Callback().Delete().After("gorm:delete").Register("test:capture_delete", capture)
+	db.Callback().Create().After("gorm:create").Register("test:capture_create", capture)
+	db.Callback().Raw().After("gorm:raw").Register("test:capture_raw", capture)
+
+	return db, &statements
+}
+
+func TestPurgeRemovesEverythingAttachedToTheTasks(t *testing.T) {
+	db, statements := setupDryRunDB(t)
+
+	_, err := Purge(db, []uint{4, 5}, 7)
+	assert.NoError(t, err)
+
+	all := strings.Join(*statements, "\n")
+	for _, expected := range []string{
+		"DELETE FROM comment_mentions WHERE comment_id IN (SELECT id FROM comments WHERE task_id IN (4,5))",
+		"DELETE FROM comment_revisions WHERE comment_id IN (SELECT id FROM comments WHERE task_id IN (4,5))",
+		"DELETE FROM comments WHERE task_id IN (4,5)",
+		"DELETE FROM attachments WHERE task_id IN (4,5)",
+		"DELETE FROM checklist_items WHERE task_id IN (4,5)",
+		"DELETE FROM task_shares WHERE task_id IN (4,5)",
+		"DELETE FROM task_assignees WHERE task_id IN (4,5)",
+		"DELETE FROM task_dependencies WHERE task_id IN (4,5) OR blocked_by_id IN (4,5)",
+		"DELETE FROM task_status_changes WHERE task_id IN (4,5)",
+		"DELETE FROM task_labels WHERE task_id IN (4,5)",
+		"UPDATE tasks SET parent_id=NULL",
+		"DELETE FROM tasks WHERE id IN (4,5)",
+		"INSERT INTO task_history",
+	} {
+		assert.Contains(t, all, expected)
+	}
+
+	// History is the one thing that survives
+	assert.NotContains(t, all, "DELETE FROM task_history")
+}
+
+func TestTickSelectsTasksPastRetention(t *testing.T) {
+	db, statements := setupDryRunDB(t)
+
+	janitor := Janitor{DB: db, Interval: time.Hour, Retention: 48 * time.Hour}
+	now := time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, janitor.Tick(context.Background(), now))
+	assert.Len(t, *statements, 1)
+	assert.Contains(t, (*statements)[0], "deleted_at IS NOT NULL AND deleted_at < '2024-05-01 10:00:00'")
+}
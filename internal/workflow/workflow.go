@@ -0,0 +1,84 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"task-manager/internal/models"
+)
+
+// DefaultSpec moves tasks from new through ongoing to completed, lets work be
+// blocked or cancelled on the way and allows completed or cancelled tasks to
+// be reopened.
+const DefaultSpec = "new:ongoing,blocked,cancelled;" +
+	"ongoing:completed,blocked,cancelled,new;" +
+	"blocked:ongoing,cancelled;" +
+	"completed:ongoing;" +
+	"cancelled:new"
+
+// Workflow is the set of allowed status transitions.
+type Workflow struct {
+	transitions map[models.TaskStatus][]models.TaskStatus
+}
+
+func Default() *Workflow {
+	wf, err := Parse(DefaultSpec)
+	if err != nil {
+		panic(err)
+	}
+	return wf
+}
+
+// Parse reads a spec in the form "from:to,to;from:to", e.g. "new:ongoing;ongoing:completed".
+func Parse(spec string) (*Workflow, error) {
+	wf := &Workflow{transitions: map[models.TaskStatus][]models.TaskStatus{}}
+
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		from, targets, found := strings.Cut(rule, ":")
+		if !found {
+			return nil, fmt.Errorf("rule %q must look like from:to,to", rule)
+		}
+
+		fromStatus := models.TaskStatus(strings.TrimSpace(from))
+		if !fromStatus.Valid() {
+			return nil, fmt.Errorf("unknown status %q", from)
+		}
+
+		for _, to := range strings.Split(targets, ",") {
+			toStatus := models.TaskStatus(strings.TrimSpace(to))
+			if !toStatus.Valid() {
+				return nil, fmt.Errorf("unknown status %q", to)
+			}
+			if toStatus == fromStatus {
+				return nil, fmt.Errorf("status %q can't transition to itself", from)
+			}
+			if !wf.Allowed(fromStatus, toStatus) {
+				wf.transitions[fromStatus] = append(wf.transitions[fromStatus], toStatus)
+			}
+		}
+	}
+
+	if len(wf.transitions) == 0 {
+		return nil, fmt.Errorf("workflow has no transitions")
+	}
+
+	return wf, nil
+}
+
+func (wf *Workflow) Allowed(from, to models.TaskStatus) bool {
+	for _, status := range wf.transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Next lists the statuses a task in the given status may move to.
+func (wf *Workflow) Next(from models.TaskStatus) []models.TaskStatus {
+	return wf.transitions[from]
+}
@@ -0,0 +1,62 @@
+package workflow
+
+import (
+	"task-manager/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultWorkflow(t *testing.T) {
+	wf := Default()
+
+	tests := []struct {
+		name    string
+		from    models.TaskStatus
+		to      models.TaskStatus
+		allowed bool
+	}{
+		{name: "Start work", from: models.StatusNew, to: models.StatusOngoing, allowed: true},
+		{name: "Complete work", from: models.StatusOngoing, to: models.StatusCompleted, allowed: true},
+		{name: "Reopen completed", from: models.StatusCompleted, to: models.StatusOngoing, allowed: true},
+		{name: "Reopen cancelled", from: models.StatusCancelled, to: models.StatusNew, allowed: true},
+		{name: "Unblock", from: models.StatusBlocked, to: models.StatusOngoing, allowed: true},
+		{name: "Skip ongoing", from: models.StatusNew, to: models.StatusCompleted, allowed: false},
+		{name: "Complete blocked", from: models.StatusBlocked, to: models.StatusCompleted, allowed: false},
+		{name: "Same status", from: models.StatusNew, to: models.StatusNew, allowed: false},
+		{name: "Unknown status", from: models.StatusNew, to: "done", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, wf.Allowed(tt.from, tt.to))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "Valid Spec", spec: "new:ongoing; ongoing:completed,new", wantErr: false},
+		{name: "Unknown From Status", spec: "done:new", wantErr: true},
+		{name: "Unknown To Status", spec: "new:done", wantErr: true},
+		{name: "Missing Separator", spec: "new", wantErr: true},
+		{name: "Self Transition", spec: "new:new", wantErr: true},
+		{name: "Empty Spec", spec: " ; ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf, err := Parse(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []models.TaskStatus{models.StatusCompleted, models.StatusNew}, wf.Next(models.StatusOngoing))
+		})
+	}
+}
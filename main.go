@@ -1,19 +1,49 @@
 package main
 
 import (
+	"context"
 	"task-manager/config"
+	"task-manager/internal/handlers"
+	"task-manager/internal/reminders"
 	"task-manager/internal/routers"
+	"task-manager/internal/trash"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func init() {
 	config.LoadEnv()
+	config.LoadWorkflow()
+	config.LoadStorage()
+	config.LoadSessions()
+	config.LoadMailer()
 	config.ConnectDB()
+	config.LoadRevocations()
 	config.SyncDB()
 }
 
 func main() {
+	scheduler := reminders.Scheduler{
+		DB:       config.DB,
+		Notifier: reminders.LogNotifier{},
+		Interval: config.GetDuration("REMINDER_INTERVAL", time.Minute),
+		Lead:     config.GetDuration("REMINDER_LEAD", time.Hour),
+	}
+	go scheduler.Run(context.Background())
+
+	janitor := trash.Janitor{
+		DB:        config.DB,
+		Storage:   config.Storage,
+		Interval:  config.GetDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		Retention: config.GetDuration("TRASH_RETENTION", 30*24*time.Hour),
+	}
+	go janitor.Run(context.Background())
+
+	go config.Revocations.Run(context.Background())
+
+	go handlers.SendPasswordResets(context.Background())
+
 	r := gin.Default()
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -22,5 +52,8 @@ func main() {
 	})
 	routers.TaskRouter(r)
 	routers.UserRouter(r)
+	routers.LabelRouter(r)
+	routers.ProjectRouter(r)
+	routers.TeamRouter(r)
 	r.Run()
 }